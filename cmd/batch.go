@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"1merge/internal/items"
+	"1merge/internal/models"
+)
+
+// plannedAction is what happens to a group whose decision was made before any merge is applied.
+type plannedAction int
+
+const (
+	// planSkip leaves the group alone; it is also the decision for groups that were never answered.
+	planSkip plannedAction = iota
+	planMerge
+	// planIgnore leaves the group alone in this and every later run.
+	planIgnore
+	// planLater leaves a group that was not reached before quitting for a later run: it is not
+	// processed or recorded in the checkpoint, so --resume asks about it again.
+	planLater
+)
+
+// plannedDecision is a decision collected up front with --batch or --tui and applied afterwards.
+type plannedDecision struct {
+	action plannedAction
+	// winnerID is the chosen winner; empty selects it automatically.
+	winnerID string
+	// policy holds the group's answers to the questions of promptGroupPolicy; nil uses mergePolicy.
+	policy *items.MergePolicy
+	// reason is reported in the decision event.
+	reason string
+}
+
+// collectDecisions shows each group in keys and asks whether to merge it, along with the questions
+// of promptGroupPolicy, without applying anything. Groups that cannot be merged are left out; they
+// are reported when the decisions are applied. Answering q stops the questions early, and the groups
+// not answered yet are planned for later (planLater).
+func collectDecisions(reader *bufio.Reader, keys []string, groups map[string][]models.Item) (map[string]plannedDecision, error) {
+	planned := make(map[string]plannedDecision)
+	for i, groupKey := range keys {
+		groupItems, ok := groups[groupKey]
+		if !ok {
+			continue
+		}
+		if _, err := items.SelectWinnerForMerge(groupItems); errors.Is(err, items.ErrMultiplePasskeys) {
+			continue
+		}
+
+		displayDuplicateGroup(groupKey, groupItems)
+		response, err := promptUser(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		switch response {
+		case "q":
+			for _, remaining := range keys[i:] {
+				if _, ok := groups[remaining]; ok {
+					planned[remaining] = plannedDecision{action: planLater}
+				}
+			}
+			fmt.Fprintf(stdout, "Stopped answering; the remaining %d groups are left for a later run.\n", len(keys)-i)
+			return planned, nil
+		case "n":
+			planned[groupKey] = plannedDecision{action: planSkip, reason: "batch"}
+		case "y":
+			groupPolicy, err := promptGroupPolicy(reader, groupItems)
+			if err != nil {
+				return nil, err
+			}
+			planned[groupKey] = plannedDecision{action: planMerge, policy: groupPolicy, reason: "batch"}
+		}
+	}
+	return planned, nil
+}
+
+// confirmDecisions lists the collected decisions and lets the user switch any group between merge
+// and skip before applying them. It returns false if the user quits without applying.
+func confirmDecisions(reader *bufio.Reader, keys []string, groups map[string][]models.Item, planned map[string]plannedDecision) (bool, error) {
+	var listed []string
+	for _, groupKey := range keys {
+		if decision, ok := planned[groupKey]; ok && decision.action != planLater {
+			listed = append(listed, groupKey)
+		}
+	}
+
+	for {
+		merges := 0
+		fmt.Fprintln(stdout, "\n=== Decisions ===")
+		for i, groupKey := range listed {
+			decision := planned[groupKey]
+			action := "skip"
+			if decision.action == planMerge {
+				action = "merge"
+				merges++
+			}
+			fmt.Fprintf(stdout, "  %d. %-5s %s (%d items)\n", i+1, action, groupKey, len(groups[groupKey]))
+		}
+		if len(listed) == 0 {
+			fmt.Fprintln(stdout, "  No groups were answered.")
+		}
+		fmt.Fprintf(stdout, "Merge: %d  Skip: %d\n", merges, len(listed)-merges)
+
+		fmt.Fprint(stdout, "Apply these decisions? (y to apply, a number to switch that group between merge and skip, q to quit without changes): ")
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return false, err
+		}
+
+		response := strings.ToLower(strings.TrimSpace(line))
+		switch response {
+		case "y":
+			return true, nil
+		case "q":
+			return false, nil
+		}
+
+		choice, err := strconv.Atoi(response)
+		if err != nil || choice < 1 || choice > len(listed) {
+			fmt.Fprintln(stdout, "Invalid input. Please enter 'y', 'q' or the number of a group.")
+			continue
+		}
+
+		groupKey := listed[choice-1]
+		decision := planned[groupKey]
+		if decision.action == planMerge {
+			planned[groupKey] = plannedDecision{action: planSkip, reason: "batch"}
+			continue
+		}
+		displayDuplicateGroup(groupKey, groups[groupKey])
+		groupPolicy, err := promptGroupPolicy(reader, groups[groupKey])
+		if err != nil {
+			return false, err
+		}
+		planned[groupKey] = plannedDecision{action: planMerge, policy: groupPolicy, reason: "batch"}
+	}
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+
+	"1merge/internal/models"
+)
+
+func batchGroups() ([]string, map[string][]models.Item) {
+	passkey := models.Field{Type: "PASSKEY", Label: "passkey"}
+	groups := map[string][]models.Item{
+		"a.com|me": {{ID: "a1", Title: "A"}, {ID: "a2", Title: "A copy"}},
+		"b.com|me": {{ID: "b1", Title: "B"}, {ID: "b2", Title: "B copy"}},
+		"c.com|me": {{ID: "c1", Title: "C", Fields: []models.Field{passkey}}, {ID: "c2", Title: "C copy", Fields: []models.Field{passkey}}},
+		"d.com|me": {{ID: "d1", Title: "D"}, {ID: "d2", Title: "D copy"}},
+	}
+	return []string{"a.com|me", "b.com|me", "c.com|me", "d.com|me"}, groups
+}
+
+func silenceStdout(t *testing.T) {
+	t.Helper()
+	oldStdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	t.Cleanup(func() {
+		w.Close()
+		os.Stdout = oldStdout
+	})
+}
+
+func TestCollectDecisions(t *testing.T) {
+	silenceStdout(t)
+	keys, groups := batchGroups()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]plannedAction
+	}{
+		// The passkey group c.com is never asked about
+		{"all answered", "y\nn\ny\n", map[string]plannedAction{"a.com|me": planMerge, "b.com|me": planSkip, "d.com|me": planMerge}},
+		// Every group not answered yet, including the passkey group, is left for a later run
+		{"quit early", "y\nq\n", map[string]plannedAction{"a.com|me": planMerge, "b.com|me": planLater, "c.com|me": planLater, "d.com|me": planLater}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := bufio.NewReader(strings.NewReader(tt.input))
+			planned, err := collectDecisions(reader, keys, groups)
+			if err != nil {
+				t.Fatalf("collectDecisions returned error: %v", err)
+			}
+			if len(planned) != len(tt.expected) {
+				t.Fatalf("expected %d decisions, got %v", len(tt.expected), planned)
+			}
+			for key, action := range tt.expected {
+				if planned[key].action != action {
+					t.Fatalf("decision for %s = %v, expected %v", key, planned[key].action, action)
+				}
+			}
+		})
+	}
+}
+
+func TestConfirmDecisions(t *testing.T) {
+	silenceStdout(t)
+	keys, groups := batchGroups()
+
+	tests := []struct {
+		name      string
+		input     string
+		confirmed bool
+		expected  map[string]plannedAction
+	}{
+		{"apply", "y\n", true, map[string]plannedAction{"a.com|me": planMerge, "b.com|me": planSkip}},
+		{"switch both then apply", "x\n1\n2\ny\n", true, map[string]plannedAction{"a.com|me": planSkip, "b.com|me": planMerge}},
+		{"quit", "1\nq\n", false, map[string]plannedAction{"a.com|me": planSkip, "b.com|me": planSkip}},
+		// d.com was not reached before quitting the questions, so it is not listed as group 3
+		{"groups left for later are not listed", "3\ny\n", true, map[string]plannedAction{"a.com|me": planMerge, "d.com|me": planLater}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			planned := map[string]plannedDecision{
+				"a.com|me": {action: planMerge},
+				"b.com|me": {action: planSkip},
+				"d.com|me": {action: planLater},
+			}
+			reader := bufio.NewReader(strings.NewReader(tt.input))
+			confirmed, err := confirmDecisions(reader, keys, groups, planned)
+			if err != nil {
+				t.Fatalf("confirmDecisions returned error: %v", err)
+			}
+			if confirmed != tt.confirmed {
+				t.Fatalf("confirmed = %v, expected %v", confirmed, tt.confirmed)
+			}
+			for key, action := range tt.expected {
+				if planned[key].action != action {
+					t.Fatalf("decision for %s = %v, expected %v", key, planned[key].action, action)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"1merge/internal/checkpoint"
+)
+
+// openCheckpoint loads the saved checkpoint when resuming, or starts a fresh one otherwise.
+// A fresh run removes any earlier checkpoint right away, so quitting before the first group is
+// saved cannot leave a stale checkpoint for --resume. Dry runs leave it in place.
+func openCheckpoint(vault string, resume, dryRun bool) (*checkpoint.Checkpoint, error) {
+	if resume {
+		return checkpoint.Load(vault)
+	}
+	cp, err := checkpoint.New(vault)
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		if err := cp.Clear(); err != nil {
+			return nil, err
+		}
+	}
+	return cp, nil
+}
+
+// recordGroup marks a group's outcome in the checkpoint and persists it.
+// Dry runs change nothing, so their outcomes are never saved.
+// Save failures are reported but do not stop the run.
+func recordGroup(cp *checkpoint.Checkpoint, groupKey string, status checkpoint.Status, dryRun bool) {
+	if dryRun {
+		return
+	}
+	cp.Mark(groupKey, status)
+	if err := cp.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"testing"
+
+	"1merge/internal/checkpoint"
+)
+
+func TestOpenCheckpoint(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	saveOld := func() {
+		t.Helper()
+		old, err := checkpoint.New("Private")
+		if err != nil {
+			t.Fatalf("New returned error: %v", err)
+		}
+		old.Mark("google.com|me", checkpoint.StatusSkipped)
+		if err := old.Save(); err != nil {
+			t.Fatalf("Save returned error: %v", err)
+		}
+	}
+	recorded := func() int {
+		t.Helper()
+		cp, err := openCheckpoint("Private", true, false)
+		if err != nil {
+			t.Fatalf("openCheckpoint returned error: %v", err)
+		}
+		return cp.Len()
+	}
+
+	saveOld()
+	if recorded() != 1 {
+		t.Fatal("expected --resume to load the saved checkpoint")
+	}
+
+	// A dry run keeps the earlier checkpoint for a later --resume
+	if _, err := openCheckpoint("Private", false, true); err != nil {
+		t.Fatalf("openCheckpoint returned error: %v", err)
+	}
+	if recorded() != 1 {
+		t.Fatal("expected a dry run to keep the saved checkpoint")
+	}
+
+	// A fresh run discards it before saving anything
+	cp, err := openCheckpoint("Private", false, false)
+	if err != nil {
+		t.Fatalf("openCheckpoint returned error: %v", err)
+	}
+	if cp.Len() != 0 || recorded() != 0 {
+		t.Fatal("expected a fresh run to remove the stale checkpoint")
+	}
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"1merge/internal/config"
+	"1merge/internal/events"
+)
+
+var (
+	configPath  string
+	profileName string
+
+	// appConfig is the loaded config file; it is empty when there is none.
+	appConfig = &config.Config{}
+	// settingSources records where each flag's value came from, for "config show".
+	settingSources map[string]string
+)
+
+// envPrefix starts the environment variables that set flags, e.g. ONEMERGE_VAULT for --vault.
+const envPrefix = "ONEMERGE_"
+
+// configOnlyFlags are flags that choose the config itself, so the config cannot set them.
+var configOnlyFlags = map[string]bool{"config": true, "profile": true, "help": true}
+
+// loadConfig reads the config file and applies it to the flags the user did not set.
+// Precedence, highest first: command-line flag, ONEMERGE_* environment variable, the selected
+// profile, the file's defaults, the flag's built-in default.
+func loadConfig(flags *pflag.FlagSet) error {
+	path, required := configPath, configPath != ""
+	if path == "" {
+		path, required = os.Getenv(envPrefix+"CONFIG"), os.Getenv(envPrefix+"CONFIG") != ""
+	}
+	if path == "" {
+		defaultPath, err := config.Path()
+		if err != nil {
+			return err
+		}
+		path = defaultPath
+	}
+	if profileName == "" {
+		profileName = os.Getenv(envPrefix + "PROFILE")
+	}
+
+	cfg, err := config.Load(path, required)
+	if err != nil {
+		return err
+	}
+	sources, err := applySettings(flags, cfg, profileName)
+	if err != nil {
+		return err
+	}
+	appConfig, settingSources = cfg, sources
+	return nil
+}
+
+// applySettings sets every flag in flags that was not given on the command line from the
+// environment, the profile or the config defaults, in that order. It returns the source of
+// each flag's value.
+func applySettings(flags *pflag.FlagSet, cfg *config.Config, profile string) (map[string]string, error) {
+	if _, err := cfg.Settings(profile); err != nil {
+		return nil, err
+	}
+	for _, values := range []map[string]config.Value{cfg.Defaults, cfg.Profiles[profile]} {
+		for name := range values {
+			if flags.Lookup(name) == nil || configOnlyFlags[name] {
+				return nil, fmt.Errorf("unknown setting %q in %s", name, cfg.Path())
+			}
+		}
+	}
+
+	sources := make(map[string]string)
+	var err error
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if err != nil || configOnlyFlags[flag.Name] {
+			return
+		}
+		if flag.Changed {
+			sources[flag.Name] = "flag"
+			return
+		}
+
+		source, value, ok := "", "", false
+		if env, set := os.LookupEnv(envName(flag.Name)); set {
+			source, value, ok = "environment "+envName(flag.Name), env, true
+		} else if v, set := cfg.Profiles[profile][flag.Name]; set && profile != "" {
+			source, value, ok = fmt.Sprintf("profile %q", profile), string(v), true
+		} else if v, set := cfg.Defaults[flag.Name]; set {
+			source, value, ok = "config defaults", string(v), true
+		}
+		if !ok {
+			sources[flag.Name] = "default"
+			return
+		}
+		if setErr := flags.Set(flag.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid value %q for %s from %s: %w", value, flag.Name, source, setErr)
+			return
+		}
+		sources[flag.Name] = source
+	})
+	return sources, err
+}
+
+// envName returns the environment variable for a flag, e.g. ONEMERGE_OLDER_THAN for --older-than.
+func envName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// ignoredByConfig reports whether the config file's ignore list names a group.
+func ignoredByConfig(groupKey string) bool {
+	for _, key := range appConfig.Ignore {
+		if strings.EqualFold(strings.TrimSpace(key), groupKey) {
+			return true
+		}
+	}
+	return false
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the effective settings and where each one comes from",
+	Long: `Show prints the config file in use, the selected profile and every setting with its
+value and source. With --output json or ndjson, it writes a config event instead.`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if err := setupOutput(); err != nil {
+			return err
+		}
+		defer closeOutput()
+
+		showConfig()
+		return nil
+	},
+}
+
+// showConfig emits a config event with --output json or ndjson and prints the settings otherwise.
+func showConfig() {
+	if eventOut.Enabled() {
+		emit(events.TypeConfig, configEvent())
+		return
+	}
+	writeConfig(stdout)
+}
+
+// writeConfig prints the config file, the selected profile and every setting with its source.
+func writeConfig(w io.Writer) {
+	found := ""
+	if !appConfig.Found() {
+		found = " (not found)"
+	}
+	fmt.Fprintf(w, "Config file: %s%s\n", appConfig.Path(), found)
+	profile := profileName
+	if profile == "" {
+		profile = "(none)"
+	}
+	fmt.Fprintf(w, "Profile: %s\n", profile)
+	if names := appConfig.ProfileNames(); len(names) > 0 {
+		fmt.Fprintf(w, "Available profiles: %s\n", strings.Join(names, ", "))
+	}
+
+	fmt.Fprintln(w, "\nSettings (flag > environment > profile > config defaults > default):")
+	rootCmd.PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		if configOnlyFlags[flag.Name] {
+			return
+		}
+		fmt.Fprintf(w, "  %-18s %-24s %s\n", flag.Name, flag.Value.String(), settingSources[flag.Name])
+	})
+
+	if len(appConfig.EquivalentDomains) > 0 {
+		fmt.Fprintln(w, "\nEquivalent domains:")
+		for _, set := range appConfig.EquivalentDomains {
+			fmt.Fprintf(w, "  %s\n", strings.Join(set, ", "))
+		}
+	}
+	fmt.Fprintf(w, "\nIgnored groups in config: %d\n", len(appConfig.Ignore))
+	fmt.Fprintf(w, "Merge policy: %s\n", policySource())
+}
+
+// configEvent describes the same settings as writeConfig.
+func configEvent() events.Config {
+	event := events.Config{
+		Path:              appConfig.Path(),
+		Found:             appConfig.Found(),
+		Profile:           profileName,
+		Profiles:          appConfig.ProfileNames(),
+		Settings:          []events.Setting{},
+		EquivalentDomains: appConfig.EquivalentDomains,
+		IgnoredGroups:     len(appConfig.Ignore),
+		Policy:            policySource(),
+	}
+	if event.EquivalentDomains == nil {
+		event.EquivalentDomains = [][]string{}
+	}
+	rootCmd.PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		if configOnlyFlags[flag.Name] {
+			return
+		}
+		event.Settings = append(event.Settings, events.Setting{Name: flag.Name, Value: flag.Value.String(), Source: settingSources[flag.Name]})
+	})
+	return event
+}
+
+// policySource describes where the merge policy comes from.
+func policySource() string {
+	switch {
+	case policyPath != "":
+		return policyPath
+	case len(appConfig.Policy) > 0:
+		return "from config file"
+	}
+	return "none (conflicting fields are archived)"
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
+}
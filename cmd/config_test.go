@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	"1merge/internal/config"
+	"1merge/internal/events"
+)
+
+func TestApplySettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "defaults:\n  vault: Private\n  concurrency: 4\n  otp: newest\nprofiles:\n  work:\n    vault: Work\n    concurrency: 8\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vaultName, otp, title string
+	var workers int
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringVar(&vaultName, "vault", "", "")
+	flags.IntVar(&workers, "concurrency", 1, "")
+	flags.StringVar(&otp, "otp", "", "")
+	flags.StringVar(&title, "title", "winner", "")
+	if err := flags.Parse([]string{"--vault", "Cli"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ONEMERGE_OTP", "ask")
+
+	sources, err := applySettings(flags, cfg, "work")
+	if err != nil {
+		t.Fatalf("applySettings returned error: %v", err)
+	}
+
+	// Flag beats everything, the environment beats the file, the profile beats the defaults
+	if vaultName != "Cli" || sources["vault"] != "flag" {
+		t.Fatalf("vault = %q from %s, expected Cli from flag", vaultName, sources["vault"])
+	}
+	if otp != "ask" || sources["otp"] != "environment ONEMERGE_OTP" {
+		t.Fatalf("otp = %q from %s, expected ask from the environment", otp, sources["otp"])
+	}
+	if workers != 8 || sources["concurrency"] != `profile "work"` {
+		t.Fatalf("concurrency = %d from %s, expected 8 from the profile", workers, sources["concurrency"])
+	}
+	if title != "winner" || sources["title"] != "default" {
+		t.Fatalf("title = %q from %s, expected the built-in default", title, sources["title"])
+	}
+}
+
+func TestApplySettings_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		profile string
+	}{
+		{"unknown setting", "defaults:\n  colour: blue\n", ""},
+		{"config cannot pick a profile", "defaults:\n  profile: work\n", ""},
+		{"invalid value", "defaults:\n  concurrency: many\n", ""},
+		{"unknown profile", "profiles:\n  work:\n    vault: Work\n", "home"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := config.Load(path, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			flags.String("vault", "", "")
+			flags.String("profile", "", "")
+			flags.Int("concurrency", 1, "")
+			if _, err := applySettings(flags, cfg, tt.profile); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestShowConfig_Events(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "profiles:\n  work:\n    vault: Work\nequivalent_domains:\n  - [amazon.com, amazon.de]\nignore:\n  - example.com|me\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousConfig, previousSources := appConfig, settingSources
+	appConfig, settingSources = cfg, map[string]string{"vault": `profile "work"`}
+	t.Cleanup(func() { appConfig, settingSources = previousConfig, previousSources })
+
+	for _, format := range structuredFormats {
+		t.Run(string(format), func(t *testing.T) {
+			written := captureEvents(t, format, showConfig)
+			if len(written) != 1 || written[0].Type != events.TypeConfig {
+				t.Fatalf("expected one config event, got %+v", written)
+			}
+			var decoded events.Config
+			if err := json.Unmarshal(written[0].Data, &decoded); err != nil {
+				t.Fatalf("invalid config data: %v", err)
+			}
+			if decoded.Path != path || !decoded.Found || len(decoded.Profiles) != 1 || len(decoded.EquivalentDomains) != 1 || decoded.IgnoredGroups != 1 {
+				t.Fatalf("unexpected config event: %+v", decoded)
+			}
+			found := false
+			for _, setting := range decoded.Settings {
+				if setting.Name == "vault" {
+					found = setting.Source == `profile "work"`
+				}
+			}
+			if !found {
+				t.Fatalf("expected the vault setting with its source, got %+v", decoded.Settings)
+			}
+		})
+	}
+}
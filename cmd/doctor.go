@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"1merge/internal/doctor"
+	"1merge/internal/events"
+	"1merge/internal/op"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that 1merge can run and suggest fixes",
+	Long: `Doctor checks the 1Password CLI (installed, supported version, signed in), the
+connection to 1Password, read and edit access to the vaults a run would use,
+the temp directory, the checkpoint directory, the ignore list and the config file.
+It prints a pass/fail checklist with a suggested fix for every problem and changes nothing.
+With --output json or ndjson, each check is a doctor_check event instead.`,
+	Args: cobra.NoArgs,
+	// A broken config file is reported as a check instead of stopping the command
+	PersistentPreRunE: func(_ *cobra.Command, _ []string) error { return nil },
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cmd.SilenceUsage = true
+
+		configErr := loadConfig(cmd.Root().PersistentFlags())
+		if err := setupOutput(); err != nil {
+			return err
+		}
+		defer closeOutput()
+
+		checks := doctor.Run(doctor.Options{
+			Client:    op.DefaultClient,
+			LookPath:  exec.LookPath,
+			Vault:     vault,
+			Config:    appConfig,
+			ConfigErr: configErr,
+		})
+		if failed := reportChecks(checks); failed > 0 {
+			return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+		}
+		return nil
+	},
+}
+
+// reportChecks emits a doctor_check event for every check with --output json or ndjson and
+// prints the checklist otherwise. It returns the number of failed checks.
+func reportChecks(checks []doctor.Check) int {
+	if !eventOut.Enabled() {
+		return writeChecks(stdout, checks)
+	}
+	failed := 0
+	for _, check := range checks {
+		emit(events.TypeDoctorCheck, events.DoctorCheck{
+			Name:   check.Name,
+			Status: strings.ToLower(check.Status.String()),
+			Detail: check.Detail,
+			Fix:    check.Fix,
+		})
+		if check.Status == doctor.StatusFail {
+			failed++
+		}
+	}
+	return failed
+}
+
+// writeChecks prints the checklist and a summary line and returns the number of failed checks.
+func writeChecks(w io.Writer, checks []doctor.Check) int {
+	counts := make(map[doctor.Status]int)
+	for _, check := range checks {
+		counts[check.Status]++
+		fmt.Fprintf(w, "[%s] %s", check.Status, check.Name)
+		if check.Detail != "" {
+			fmt.Fprintf(w, ": %s", check.Detail)
+		}
+		fmt.Fprintln(w)
+		if check.Fix != "" && (check.Status == doctor.StatusFail || check.Status == doctor.StatusWarn) {
+			fmt.Fprintf(w, "       Fix: %s\n", check.Fix)
+		}
+	}
+	fmt.Fprintf(w, "\n%d passed, %d warnings, %d failed, %d skipped\n",
+		counts[doctor.StatusPass], counts[doctor.StatusWarn], counts[doctor.StatusFail], counts[doctor.StatusSkip])
+	return counts[doctor.StatusFail]
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
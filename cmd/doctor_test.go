@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"1merge/internal/doctor"
+	"1merge/internal/events"
+)
+
+func TestWriteChecks(t *testing.T) {
+	checks := []doctor.Check{
+		{Name: "op CLI installed", Status: doctor.StatusPass, Detail: "/usr/bin/op"},
+		{Name: "Signed in", Status: doctor.StatusFail, Detail: "not signed in", Fix: "Run 'eval $(op signin)'"},
+		{Name: "Vaults", Status: doctor.StatusSkip, Detail: "not signed in"},
+	}
+
+	var out bytes.Buffer
+	if failed := writeChecks(&out, checks); failed != 1 {
+		t.Fatalf("writeChecks = %d failed, expected 1", failed)
+	}
+	for _, want := range []string{
+		"[PASS] op CLI installed: /usr/bin/op\n",
+		"[FAIL] Signed in: not signed in\n       Fix: Run 'eval $(op signin)'\n",
+		"1 passed, 0 warnings, 1 failed, 1 skipped",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in output:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestReportChecks_Events(t *testing.T) {
+	checks := []doctor.Check{
+		{Name: "op CLI installed", Status: doctor.StatusPass, Detail: "/usr/bin/op"},
+		{Name: "Signed in", Status: doctor.StatusFail, Detail: "not signed in", Fix: "Run 'eval $(op signin)'"},
+	}
+
+	for _, format := range structuredFormats {
+		t.Run(string(format), func(t *testing.T) {
+			failed := 0
+			written := captureEvents(t, format, func() { failed = reportChecks(checks) })
+			if failed != 1 || len(written) != 2 {
+				t.Fatalf("expected 1 failed check and 2 events, got %d and %+v", failed, written)
+			}
+			var check events.DoctorCheck
+			if err := json.Unmarshal(written[1].Data, &check); err != nil {
+				t.Fatalf("invalid doctor_check data: %v", err)
+			}
+			if written[1].Type != events.TypeDoctorCheck || check.Status != "fail" || check.Fix == "" {
+				t.Fatalf("unexpected event: %s %+v", written[1].Type, check)
+			}
+		})
+	}
+}
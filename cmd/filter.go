@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"1merge/internal/filter"
+	"1merge/internal/models"
+)
+
+var (
+	domainPatterns  []string
+	excludePatterns []string
+	olderThan       string
+	minGroupSize    int
+	usernameRegex   string
+	itemVaults      []string
+)
+
+// loadGroupFilter builds the group filter from --domain, --exclude-domain, --older-than,
+// --min-group-size, --username-regex and --item-vault.
+func loadGroupFilter() (*filter.Filter, error) {
+	age, err := filter.ParseAge(olderThan)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --older-than: %w", err)
+	}
+	f := &filter.Filter{
+		Domains:        domainPatterns,
+		ExcludeDomains: excludePatterns,
+		OlderThan:      age,
+		MinSize:        minGroupSize,
+		Vaults:         itemVaults,
+	}
+	if usernameRegex != "" {
+		// Group keys hold lowercased usernames, so the match ignores case
+		re, err := regexp.Compile("(?i)" + usernameRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --username-regex: %w", err)
+		}
+		f.Username = re
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// filterGroups applies the group filter flags to the groups found by GroupDuplicates.
+func filterGroups(groups map[string][]models.Item) (map[string][]models.Item, error) {
+	f, err := loadGroupFilter()
+	if err != nil {
+		return nil, err
+	}
+	return f.Apply(groups, time.Now()), nil
+}
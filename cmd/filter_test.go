@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestLoadGroupFilter(t *testing.T) {
+	oldAge, oldRegex, oldDomains := olderThan, usernameRegex, domainPatterns
+	t.Cleanup(func() { olderThan, usernameRegex, domainPatterns = oldAge, oldRegex, oldDomains })
+
+	tests := []struct {
+		name    string
+		age     string
+		regex   string
+		domains []string
+		wantErr bool
+	}{
+		{"no filters", "", "", nil, false},
+		{"valid", "2y", "^admin", []string{"*.amazon.*"}, false},
+		{"bad age", "two years", "", nil, true},
+		{"bad regex", "", "(", nil, true},
+		{"bad pattern", "", "", []string{"[google"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			olderThan, usernameRegex, domainPatterns = tt.age, tt.regex, tt.domains
+			f, err := loadGroupFilter()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadGroupFilter error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tt.regex != "" && !f.Username.MatchString("ADMIN@example.com") {
+				t.Fatal("expected the username regex to ignore case")
+			}
+		})
+	}
+}
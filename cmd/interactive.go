@@ -3,14 +3,22 @@ package cmd
 import (
 	"bufio"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
 	"time"
 
+	"1merge/internal/items"
 	"1merge/internal/models"
 )
 
 // displayDuplicateGroup displays information about a duplicate group to help user make merge decisions.
-func displayDuplicateGroup(groupKey string, items []models.Item) {
+func displayDuplicateGroup(groupKey string, groupItems []models.Item) {
+	writeDuplicateGroup(stdout, groupKey, groupItems)
+}
+
+// writeDuplicateGroup writes the duplicate group display to w.
+func writeDuplicateGroup(w io.Writer, groupKey string, groupItems []models.Item) {
 	// Extract domain and username from groupKey (format: domain|username)
 	parts := strings.Split(groupKey, "|")
 	domain := parts[0]
@@ -19,26 +27,46 @@ func displayDuplicateGroup(groupKey string, items []models.Item) {
 		username = parts[1]
 	}
 
-	fmt.Printf("\n=== Duplicate Group: %s | %s ===\n", domain, username)
-	fmt.Printf("Found %d duplicate items:\n", len(items))
+	fmt.Fprintf(w, "\n=== Duplicate Group: %s | %s ===\n", domain, username)
+	fmt.Fprintf(w, "Found %d duplicate items:\n", len(groupItems))
 
-	for i, item := range items {
+	passkeys := 0
+	for i, item := range groupItems {
 		idDisplay := item.ID
 		if len(item.ID) > 8 {
 			idDisplay = item.ID[:8] + "..."
 		}
-		fmt.Printf("  %d. %q (ID: %s) - Updated: %s\n", i+1, item.Title, idDisplay, formatTimestamp(item.UpdatedAt))
+		fmt.Fprintf(w, "  %d. %q (ID: %s) - Updated: %s\n", i+1, item.Title, idDisplay, formatTimestamp(item.UpdatedAt))
 		if len(item.URLs) > 0 {
-			fmt.Printf("     URL: %s\n", item.URLs[0].HRef)
+			fmt.Fprintf(w, "     URL: %s\n", item.URLs[0].HRef)
+		}
+		if len(item.Files) > 0 {
+			fmt.Fprintf(w, "     Attachments: %d\n", len(item.Files))
+		}
+		if items.HasOTP(item) {
+			fmt.Fprintln(w, "     OTP: yes")
+		}
+		if items.HasPasskey(item) {
+			fmt.Fprintln(w, "     Passkey: yes")
+			passkeys++
 		}
 	}
-	fmt.Println()
+	switch {
+	case passkeys == 1:
+		fmt.Fprintln(w, "Note: the item holding a passkey is kept as the winner, since passkeys cannot be moved.")
+	case passkeys > 1:
+		fmt.Fprintf(w, "Blocked: %d items hold passkeys; merging would archive a passkey, which cannot be moved to another item.\n", passkeys)
+	}
+	if secrets := items.DistinctOTPSecrets(groupItems); secrets > 1 {
+		fmt.Fprintf(w, "Warning: these items have %d different one-time password secrets; only one stays active, the others are moved to Archived Conflicts.\n", secrets)
+	}
+	fmt.Fprintln(w)
 }
 
 // promptUser prompts user for y/n/q input and returns normalized response.
 func promptUser(reader *bufio.Reader) (string, error) {
 	for {
-		fmt.Print("Merge these items? (y/n/q): ")
+		fmt.Fprint(stdout, "Merge these items? (y/n/q): ")
 		line, err := reader.ReadString('\n')
 		if err != nil {
 			return "", err
@@ -52,7 +80,161 @@ func promptUser(reader *bufio.Reader) (string, error) {
 		}
 
 		// Invalid input, prompt again
-		fmt.Println("Invalid input. Please enter 'y', 'n', or 'q'.")
+		fmt.Fprintln(stdout, "Invalid input. Please enter 'y', 'n', or 'q'.")
+	}
+}
+
+// promptOTPChoice asks which item's one-time password should stay active and returns its ID.
+// Only items with an active one-time password are offered.
+func promptOTPChoice(reader *bufio.Reader, groupItems []models.Item) (string, error) {
+	var candidates []models.Item
+	for _, item := range groupItems {
+		if items.HasOTP(item) {
+			candidates = append(candidates, item)
+		}
+	}
+
+	fmt.Fprintln(stdout, "Which one-time password should stay active?")
+	for i, item := range candidates {
+		fmt.Fprintf(stdout, "  %d. %q (Updated: %s)\n", i+1, item.Title, formatTimestamp(item.UpdatedAt))
+	}
+
+	for {
+		fmt.Fprintf(stdout, "Keep OTP from (1-%d): ", len(candidates))
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+
+		choice, err := strconv.Atoi(strings.TrimSpace(line))
+		if err == nil && choice >= 1 && choice <= len(candidates) {
+			return candidates[choice-1].ID, nil
+		}
+
+		fmt.Fprintf(stdout, "Invalid input. Please enter a number from 1 to %d.\n", len(candidates))
+	}
+}
+
+// titleOptions lists the titles offered for a merged item: the winner's title, the group's
+// clean titles and the title derived from the website, without repeats.
+func titleOptions(groupItems []models.Item) []string {
+	winner, err := items.SelectWinnerForMerge(groupItems)
+	if err != nil {
+		return nil
+	}
+
+	var options []string
+	seen := make(map[string]bool)
+	for _, title := range append(append([]string{winner.Title}, items.TitleCandidates(groupItems)...), items.DomainTitle(winner)) {
+		key := strings.ToLower(title)
+		if title == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		options = append(options, title)
+	}
+	return options
+}
+
+// promptTitleChoice asks which title the merged item should get and returns it.
+// The first option is the winner's title, which is also kept if the user just presses Enter.
+func promptTitleChoice(reader *bufio.Reader, options []string) (string, error) {
+	fmt.Fprintln(stdout, "Which title should the merged item have?")
+	for i, title := range options {
+		fmt.Fprintf(stdout, "  %d. %q\n", i+1, title)
+	}
+
+	for {
+		fmt.Fprintf(stdout, "Title (1-%d, Enter for 1): ", len(options))
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return options[0], nil
+		}
+		choice, err := strconv.Atoi(line)
+		if err == nil && choice >= 1 && choice <= len(options) {
+			return options[choice-1], nil
+		}
+
+		fmt.Fprintf(stdout, "Invalid input. Please enter a number from 1 to %d.\n", len(options))
+	}
+}
+
+// promptPrimaryURLChoice asks which of the group's primary URLs stays primary and returns it.
+// The first option is the winner's, which is also kept if the user just presses Enter.
+func promptPrimaryURLChoice(reader *bufio.Reader, options []string) (string, error) {
+	fmt.Fprintln(stdout, "The items have different primary websites. Which should stay primary?")
+	for i, href := range options {
+		fmt.Fprintf(stdout, "  %d. %s\n", i+1, href)
+	}
+
+	for {
+		fmt.Fprintf(stdout, "Primary URL (1-%d, Enter for 1): ", len(options))
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return options[0], nil
+		}
+		choice, err := strconv.Atoi(line)
+		if err == nil && choice >= 1 && choice <= len(options) {
+			return options[choice-1], nil
+		}
+
+		fmt.Fprintf(stdout, "Invalid input. Please enter a number from 1 to %d.\n", len(options))
+	}
+}
+
+// promptGroupPolicy asks for the choices mergePolicy leaves open for a group: which one-time password
+// stays active, which URL is primary and which title the merged item gets. Questions that do not
+// apply to the group are not asked.
+func promptGroupPolicy(reader *bufio.Reader, groupItems []models.Item) (*items.MergePolicy, error) {
+	groupPolicy := mergePolicy
+	if mergePolicy != nil && mergePolicy.OTP == items.OTPAsk && items.DistinctOTPSecrets(groupItems) > 1 {
+		otpFrom, err := promptOTPChoice(reader, groupItems)
+		if err != nil {
+			return nil, err
+		}
+		groupPolicy = mergePolicy.WithOTP(items.OTPAsk, otpFrom)
+	}
+
+	if winner, err := items.SelectWinnerForMerge(groupItems); err == nil {
+		if options := items.PrimaryURLOptions(winner, groupItems, groupPolicy); len(options) > 1 {
+			href, err := promptPrimaryURLChoice(reader, options)
+			if err != nil {
+				return nil, err
+			}
+			groupPolicy = groupPolicy.WithPrimaryURL(href)
+		}
+	}
+
+	if mergePolicy != nil && mergePolicy.Title == items.TitleAsk {
+		if options := titleOptions(groupItems); len(options) > 1 {
+			title, err := promptTitleChoice(reader, options)
+			if err != nil {
+				return nil, err
+			}
+			groupPolicy = groupPolicy.WithTitle(items.TitleAsk, title)
+		}
+	}
+	return groupPolicy, nil
+}
+
+// writeDefaultChoices notes the defaults used for the questions promptGroupPolicy would ask,
+// when a group is merged without prompting. label names the mode, e.g. "[AUTO MODE]".
+func writeDefaultChoices(w io.Writer, label string, groupItems []models.Item) {
+	if mergePolicy != nil && mergePolicy.OTP == items.OTPAsk && items.DistinctOTPSecrets(groupItems) > 1 {
+		fmt.Fprintln(w, label, "Keeping the winner's one-time password active")
+	}
+	if mergePolicy != nil && mergePolicy.Title == items.TitleAsk {
+		fmt.Fprintln(w, label, "Keeping the winner's title")
 	}
 }
 
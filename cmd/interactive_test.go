@@ -102,3 +102,95 @@ func TestFormatTimestamp(t *testing.T) {
 		t.Fatalf("formatTimestamp(%v) = %q, expected %q", testTime, result, expected)
 	}
 }
+
+func TestPromptOTPChoice(t *testing.T) {
+	groupItems := []models.Item{
+		{ID: "no-otp", Title: "Plain"},
+		{ID: "otp-a", Title: "A", Fields: []models.Field{{Type: "OTP", Label: "one-time password", Value: "AAAA"}}},
+		{ID: "otp-b", Title: "B", Fields: []models.Field{{Type: "OTP", Label: "one-time password", Value: "BBBB"}}},
+	}
+
+	// Silence prompt output during the test
+	oldStdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	t.Cleanup(func() {
+		w.Close()
+		os.Stdout = oldStdout
+	})
+
+	// Only items with an OTP are offered, so "2" selects otp-b; invalid entries are re-prompted
+	reader := bufio.NewReader(strings.NewReader("x\n3\n2\n"))
+	choice, err := promptOTPChoice(reader, groupItems)
+	if err != nil {
+		t.Fatalf("promptOTPChoice returned error: %v", err)
+	}
+	if choice != "otp-b" {
+		t.Fatalf("promptOTPChoice = %q, expected %q", choice, "otp-b")
+	}
+}
+
+func TestPromptTitleChoice(t *testing.T) {
+	groupItems := []models.Item{
+		{ID: "old", Title: "Google", UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "new", Title: "accounts.google.com (2)", UpdatedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			URLs: []models.URL{{HRef: "https://accounts.google.com", Primary: true}}},
+		{ID: "copy", Title: "Google - Copy", UpdatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	// Winner's title first, then the clean titles; the domain title "Google" is already listed
+	options := titleOptions(groupItems)
+	expected := []string{"accounts.google.com (2)", "Google"}
+	if strings.Join(options, "|") != strings.Join(expected, "|") {
+		t.Fatalf("titleOptions = %q, expected %q", options, expected)
+	}
+
+	// Silence prompt output during the test
+	oldStdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	t.Cleanup(func() {
+		w.Close()
+		os.Stdout = oldStdout
+	})
+
+	reader := bufio.NewReader(strings.NewReader("7\n2\n\n"))
+	title, err := promptTitleChoice(reader, options)
+	if err != nil {
+		t.Fatalf("promptTitleChoice returned error: %v", err)
+	}
+	if title != "Google" {
+		t.Fatalf("promptTitleChoice = %q, expected %q", title, "Google")
+	}
+
+	// Enter keeps the winner's title
+	title, err = promptTitleChoice(reader, options)
+	if err != nil || title != options[0] {
+		t.Fatalf("promptTitleChoice = %q, %v; expected %q", title, err, options[0])
+	}
+}
+
+func TestPromptPrimaryURLChoice(t *testing.T) {
+	// Silence prompt output during the test
+	oldStdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	t.Cleanup(func() {
+		w.Close()
+		os.Stdout = oldStdout
+	})
+
+	options := []string{"https://a.example.com", "https://b.example.com"}
+	reader := bufio.NewReader(strings.NewReader("0\n2\n\n"))
+
+	href, err := promptPrimaryURLChoice(reader, options)
+	if err != nil || href != options[1] {
+		t.Fatalf("promptPrimaryURLChoice = %q, %v; expected %q", href, err, options[1])
+	}
+
+	// Enter keeps the winner's primary URL
+	href, err = promptPrimaryURLChoice(reader, options)
+	if err != nil || href != options[0] {
+		t.Fatalf("promptPrimaryURLChoice = %q, %v; expected %q", href, err, options[0])
+	}
+}
@@ -8,13 +8,13 @@ import (
 	"1merge/internal/models"
 )
 
-// applyMergeAndReport delegates merging to items.ApplyMerge and handles user-facing success logging.
-func applyMergeAndReport(out io.Writer, winner models.Item, losers []models.Item, dryRun bool) error {
-	if err := items.ApplyMerge(winner, losers, dryRun); err != nil {
+// applyMergeAndReport delegates merging to items.ApplyMergeWithOptions and handles user-facing success logging.
+func applyMergeAndReport(out io.Writer, winner models.Item, losers []models.Item, opts items.ApplyOptions) error {
+	if err := items.ApplyMergeWithOptions(winner, losers, opts); err != nil {
 		return err
 	}
 
-	if !dryRun {
+	if !opts.DryRun {
 		fmt.Fprintf(out, "Successfully merged %d items into %s\n", len(losers), winner.ID)
 	}
 
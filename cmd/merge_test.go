@@ -39,7 +39,7 @@ func TestApplyMergeAndReport_LogsSuccess(t *testing.T) {
 	losers := []models.Item{{ID: "loser1"}, {ID: "loser2"}}
 
 	var out bytes.Buffer
-	if err := applyMergeAndReport(&out, winner, losers, false); err != nil {
+	if err := applyMergeAndReport(&out, winner, losers, items.ApplyOptions{}); err != nil {
 		t.Fatalf("applyMergeAndReport returned error: %v", err)
 	}
 
@@ -61,7 +61,7 @@ func TestApplyMergeAndReport_ForwardsError(t *testing.T) {
 	losers := []models.Item{{ID: "loser1"}}
 
 	var out bytes.Buffer
-	err := applyMergeAndReport(&out, winner, losers, false)
+	err := applyMergeAndReport(&out, winner, losers, items.ApplyOptions{})
 	if err == nil {
 		t.Fatal("expected error from applyMergeAndReport, got nil")
 	}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"1merge/internal/events"
+	"1merge/internal/items"
+	"1merge/internal/models"
+)
+
+var (
+	outputFormat string
+
+	// eventOut writes structured events to stdout with --output json or ndjson; it ignores events in text mode.
+	eventOut *events.Writer
+
+	// stdout receives human-readable output, see textWriter.
+	stdout = textWriter{}
+)
+
+// textWriter writes human-readable output to os.Stdout, or to os.Stderr while stdout is reserved
+// for events. It looks up os.Stdout on every write, so redirecting os.Stdout still works.
+type textWriter struct{}
+
+func (textWriter) Write(p []byte) (int, error) {
+	if eventOut.Enabled() {
+		return os.Stderr.Write(p)
+	}
+	return os.Stdout.Write(p)
+}
+
+// textOnly returns where commands whose result is reported as events print human-readable text:
+// stdout in text mode, nowhere with --output json or ndjson.
+func textOnly() io.Writer {
+	if eventOut.Enabled() {
+		return io.Discard
+	}
+	return stdout
+}
+
+// setupOutput validates --output and prepares eventOut.
+func setupOutput() error {
+	format, err := events.ParseFormat(outputFormat)
+	if err != nil {
+		return err
+	}
+	eventOut = events.NewWriter(os.Stdout, format)
+	return nil
+}
+
+// emit writes an event; write failures are reported but do not stop the run.
+func emit(eventType events.Type, data any) {
+	if err := eventOut.Emit(eventType, data); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// closeOutput writes any events held back until the end of the run.
+func closeOutput() {
+	if err := eventOut.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// groupFoundEvent describes a duplicate group without any field values.
+func groupFoundEvent(groupKey string, groupItems []models.Item) events.GroupFound {
+	domain, username, _ := strings.Cut(groupKey, "|")
+	event := events.GroupFound{Key: groupKey, Domain: domain, Username: username, Items: []events.Item{}}
+	for _, item := range groupItems {
+		vaultName := item.Vault.Name
+		if vaultName == "" {
+			vaultName = item.Vault.ID
+		}
+		url := ""
+		if len(item.URLs) > 0 {
+			url = item.URLs[0].HRef
+		}
+		event.Items = append(event.Items, events.Item{
+			ID:          item.ID,
+			Title:       item.Title,
+			Vault:       vaultName,
+			UpdatedAt:   item.UpdatedAt,
+			URL:         url,
+			Attachments: len(item.Files),
+			HasOTP:      items.HasOTP(item),
+			HasPasskey:  items.HasPasskey(item),
+		})
+	}
+	return event
+}
+
+// mergeFailedEvent describes a failed group, including the vault state after a rollback.
+func mergeFailedEvent(groupKey string, err error) events.MergeFailed {
+	event := events.MergeFailed{Key: groupKey, Error: err.Error()}
+	var applyErr *items.ApplyError
+	if errors.As(err, &applyErr) {
+		event.State = string(applyErr.State)
+		event.NotArchived = applyErr.NotArchived
+	}
+	return event
+}
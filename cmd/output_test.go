@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"1merge/internal/events"
+	"1merge/internal/items"
+	"1merge/internal/models"
+)
+
+func TestGroupFoundEvent(t *testing.T) {
+	groupItems := []models.Item{
+		{
+			ID:        "a",
+			Title:     "Example",
+			Vault:     models.Vault{ID: "v1", Name: "Private"},
+			UpdatedAt: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+			URLs:      []models.URL{{HRef: "https://example.com"}},
+			Fields:    []models.Field{{Type: "OTP", Label: "one-time password", Value: "SECRET"}},
+			Files:     []models.File{{ID: "f", Name: "scan.pdf"}},
+		},
+		{ID: "b", Vault: models.Vault{ID: "v2"}},
+	}
+
+	event := groupFoundEvent("example.com|me", groupItems)
+
+	if event.Domain != "example.com" || event.Username != "me" || len(event.Items) != 2 {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	first := event.Items[0]
+	if first.Vault != "Private" || first.URL != "https://example.com" || first.Attachments != 1 || !first.HasOTP || first.HasPasskey {
+		t.Errorf("unexpected first item: %+v", first)
+	}
+	if event.Items[1].Vault != "v2" {
+		t.Errorf("expected vault ID fallback, got %q", event.Items[1].Vault)
+	}
+}
+
+func TestMergeFailedEvent(t *testing.T) {
+	applyErr := &items.ApplyError{ItemID: "w", State: items.StatePartiallyMerged, Err: errors.New("archive failed"), NotArchived: []string{"l1"}}
+
+	event := mergeFailedEvent("example.com|me", fmt.Errorf("wrapped: %w", applyErr))
+	if event.State != "partially merged" || len(event.NotArchived) != 1 || event.Error == "" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+
+	plain := mergeFailedEvent("example.com|me", errors.New("boom"))
+	if plain.State != "" || plain.Error != "boom" {
+		t.Fatalf("unexpected event: %+v", plain)
+	}
+}
+
+// decodedEvent is an event read back from the output, with its data left encoded.
+type decodedEvent struct {
+	SchemaVersion int             `json:"schema_version"`
+	Type          events.Type     `json:"type"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// captureEvents runs fn with events written in format and returns the events it wrote.
+func captureEvents(t *testing.T, format events.Format, fn func()) []decodedEvent {
+	t.Helper()
+	var out bytes.Buffer
+	previous := eventOut
+	eventOut = events.NewWriter(&out, format)
+	t.Cleanup(func() { eventOut = previous })
+
+	fn()
+	closeOutput()
+
+	var decoded []decodedEvent
+	if format == events.FormatJSON {
+		var doc struct {
+			Events []decodedEvent `json:"events"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+			t.Fatalf("invalid JSON document: %v\n%s", err, out.String())
+		}
+		decoded = doc.Events
+	} else {
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			var event decodedEvent
+			if err := json.Unmarshal([]byte(line), &event); err != nil {
+				t.Fatalf("invalid NDJSON line %q: %v", line, err)
+			}
+			decoded = append(decoded, event)
+		}
+	}
+	for _, event := range decoded {
+		if event.SchemaVersion != events.SchemaVersion {
+			t.Fatalf("unexpected schema version in %+v", event)
+		}
+	}
+	return decoded
+}
+
+// structuredFormats are the --output formats that write events.
+var structuredFormats = []events.Format{events.FormatJSON, events.FormatNDJSON}
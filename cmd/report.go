@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"1merge/internal/events"
+	"1merge/internal/items"
+	"1merge/internal/op"
+	"1merge/internal/report"
+)
+
+var reportHTMLPath string
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Write a shareable report of duplicate groups",
+	Long: `Report scans the vault like a normal run but changes nothing. It writes a
+self-contained HTML page listing the duplicate groups by domain, with item titles,
+vaults, dates, proposed winners and conflict counts, plus charts of duplicates per
+vault and per domain. Usernames, passwords and other field values are never included.
+With --output json or ndjson, a report_written event is written once the page is done.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cmd.SilenceUsage = true
+
+		if concurrency < 1 {
+			return fmt.Errorf("--concurrency must be at least 1")
+		}
+		if err := setupOutput(); err != nil {
+			return err
+		}
+		defer closeOutput()
+
+		if err := loadMergePolicy(); err != nil {
+			return err
+		}
+		if err := op.VerifyOpReady(); err != nil {
+			return err
+		}
+
+		fetchedItems, err := items.FetchItems(vault)
+		if err != nil {
+			return fmt.Errorf("failed to fetch items: %w", err)
+		}
+		fmt.Fprintf(textOnly(), "Found %d login items in vault\n", len(fetchedItems))
+
+		duplicateGroups, err := filterGroups(items.GroupDuplicatesWithEquivalents(fetchedItems, appConfig.EquivalentDomains))
+		if err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(duplicateGroups))
+		for groupKey := range duplicateGroups {
+			keys = append(keys, groupKey)
+		}
+		sort.Strings(keys)
+
+		// Full details are needed for proposed winners and conflict counts
+		fmt.Fprintf(textOnly(), "Fetching details of %d duplicate groups...\n", len(keys))
+		hydrated, failed := hydrateGroups(duplicateGroups, keys, concurrency)
+		for _, key := range keys {
+			err, ok := failed[key]
+			if !ok {
+				continue
+			}
+			if op.IsAuthExpired(err) {
+				return fmt.Errorf("failed to fetch item details: %w", err)
+			}
+			// Report what the list summaries show for groups that could not be read; the error
+			// itself only goes to stderr, since the page is meant to be shared
+			fmt.Fprintf(os.Stderr, "Error fetching item details for group %s: %v\n", key, err)
+			hydrated[key] = duplicateGroups[key]
+		}
+
+		page, err := os.Create(reportHTMLPath)
+		if err != nil {
+			return fmt.Errorf("failed to create report: %w", err)
+		}
+		defer page.Close()
+
+		if err := report.WriteHTML(page, report.Build(hydrated, failed, len(fetchedItems), vault, mergePolicy)); err != nil {
+			return err
+		}
+		if err := page.Close(); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+
+		reportWritten(events.ReportWritten{Path: reportHTMLPath, Items: len(fetchedItems), Groups: len(keys), Unreadable: len(failed)})
+		return nil
+	},
+}
+
+// reportWritten emits a report_written event with --output json or ndjson and prints a
+// confirmation otherwise.
+func reportWritten(event events.ReportWritten) {
+	if eventOut.Enabled() {
+		emit(events.TypeReportWritten, event)
+		return
+	}
+	fmt.Fprintf(stdout, "Wrote report of %d duplicate groups to %s\n", event.Groups, event.Path)
+}
+
+func init() {
+	reportCmd.Flags().StringVar(&reportHTMLPath, "html", "", "Path of the HTML report to write")
+	_ = reportCmd.MarkFlagRequired("html")
+	rootCmd.AddCommand(reportCmd)
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"1merge/internal/events"
+)
+
+func TestReportWritten_Events(t *testing.T) {
+	event := events.ReportWritten{Path: "report.html", Items: 40, Groups: 3, Unreadable: 1}
+
+	for _, format := range structuredFormats {
+		t.Run(string(format), func(t *testing.T) {
+			written := captureEvents(t, format, func() { reportWritten(event) })
+			if len(written) != 1 || written[0].Type != events.TypeReportWritten {
+				t.Fatalf("expected one report_written event, got %+v", written)
+			}
+			var decoded events.ReportWritten
+			if err := json.Unmarshal(written[0].Data, &decoded); err != nil {
+				t.Fatalf("invalid report_written data: %v", err)
+			}
+			if decoded != event {
+				t.Fatalf("report_written = %+v, expected %+v", decoded, event)
+			}
+		})
+	}
+}
@@ -2,21 +2,43 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"sort"
 
 	"github.com/spf13/cobra"
 
+	"1merge/internal/checkpoint"
+	"1merge/internal/events"
+	"1merge/internal/ignore"
 	"1merge/internal/items"
-	"1merge/internal/models"
 	"1merge/internal/op"
+	"1merge/internal/workpool"
 )
 
 var (
-	vault  string
-	dryRun bool
-	auto   bool
+	vault        string
+	dryRun       bool
+	auto         bool
+	resume       bool
+	verify       bool
+	concurrency  int
+	policyPath   string
+	pwHistory    bool
+	tagMerged    bool
+	otpMode      string
+	foldWWW      bool
+	titleMode    string
+	mergeHistory bool
+	tuiMode      bool
+	batchMode    bool
+
+	// mergePolicy is loaded from --policy; nil archives every conflicting field.
+	mergePolicy *items.MergePolicy
+	// runID identifies this run in the "Merge History" section written with --merge-history.
+	runID string
 )
 
 var rootCmd = &cobra.Command{
@@ -25,13 +47,41 @@ var rootCmd = &cobra.Command{
 	Long: `1Merge is a CLI tool that helps you identify and merge duplicate login entries
 in your 1Password vaults. It can scan your vault, find duplicates, and merge them
 automatically or with your confirmation.`,
+	// The config file fills in every flag not given on the command line, for all subcommands
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		cmd.SilenceUsage = true
+		return loadConfig(cmd.Root().PersistentFlags())
+	},
 	// Error handling strategy:
 	// - Pre-flight errors (op CLI, fetch, grouping): abort immediately
 	// - Per-group errors (merge, apply): skip group and continue processing
 	// This ensures one bad group doesn't prevent processing of other duplicates.
 	Run: func(_ *cobra.Command, _ []string) {
+		if concurrency < 1 {
+			fmt.Fprintln(os.Stderr, "Error: --concurrency must be at least 1")
+			return
+		}
+		if (tuiMode && auto) || (batchMode && auto) || (tuiMode && batchMode) {
+			fmt.Fprintln(os.Stderr, "Error: only one of --auto, --batch and --tui can be used")
+			return
+		}
+		if err := setupOutput(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+		defer closeOutput()
+
 		if dryRun {
-			fmt.Println("Dry Run Mode Enabled")
+			fmt.Fprintln(stdout, "Dry Run Mode Enabled")
+		}
+		if mergeHistory {
+			runID = items.NewRunID()
+			fmt.Fprintf(stdout, "Run ID: %s\n", runID)
+		}
+
+		if err := loadMergePolicy(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
 		}
 
 		// Verify op CLI is installed and user is signed in
@@ -40,6 +90,8 @@ automatically or with your confirmation.`,
 			return
 		}
 
+		emit(events.TypeScanStarted, events.ScanStarted{Vault: vault, DryRun: dryRun, Auto: auto, RunID: runID})
+
 		// Fetch login items from 1Password
 		fetchedItems, err := items.FetchItems(vault)
 		if err != nil {
@@ -47,29 +99,86 @@ automatically or with your confirmation.`,
 			return
 		}
 
-		fmt.Printf("Found %d login items in vault\n", len(fetchedItems))
+		fmt.Fprintf(stdout, "Found %d login items in vault\n", len(fetchedItems))
 
 		// Group duplicates
-		duplicateGroups := items.GroupDuplicates(fetchedItems)
+		duplicateGroups := items.GroupDuplicatesWithEquivalents(fetchedItems, appConfig.EquivalentDomains)
+
+		if len(duplicateGroups) == 0 {
+			fmt.Fprintln(stdout, "No duplicate items found.")
+			emit(events.TypeSummary, events.Summary{Items: len(fetchedItems)})
+			return
+		}
+
+		fmt.Fprintf(stdout, "Found %d duplicate groups\n", len(duplicateGroups))
+
+		// Limit the run to the groups selected by --domain, --older-than and the other filters
+		foundGroups := len(duplicateGroups)
+		duplicateGroups, err = filterGroups(duplicateGroups)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+		if len(duplicateGroups) < foundGroups {
+			fmt.Fprintf(stdout, "Filters selected %d of %d duplicate groups\n", len(duplicateGroups), foundGroups)
+		}
 
+		// Leave out the groups the user chose to ignore in earlier runs
+		ignored, err := ignore.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading ignore list: %v\n", err)
+			return
+		}
+		ignoredGroups := 0
+		for groupKey := range duplicateGroups {
+			if ignored.Contains(groupKey) || ignoredByConfig(groupKey) {
+				delete(duplicateGroups, groupKey)
+				ignoredGroups++
+			}
+		}
+		if ignoredGroups > 0 {
+			fmt.Fprintf(stdout, "Ignoring %d groups on the ignore list\n", ignoredGroups)
+		}
 		if len(duplicateGroups) == 0 {
-			fmt.Println("No duplicate items found.")
+			fmt.Fprintln(stdout, "No duplicate groups left to review.")
+			emit(events.TypeSummary, events.Summary{Items: len(fetchedItems), Ignored: ignoredGroups})
 			return
 		}
 
-		fmt.Printf("Found %d duplicate groups\n", len(duplicateGroups))
+		// Load the checkpoint of a previous run (--resume) or start a new one
+		cp, err := openCheckpoint(vault, resume, dryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading checkpoint: %v\n", err)
+			return
+		}
+		if resume {
+			fmt.Fprintf(stdout, "Resuming from checkpoint with %d recorded groups\n", cp.Len())
+		}
 
 		// Initialize statistics tracking
 		processedGroups := 0
 		skippedGroups := 0
 		failedGroups := 0
 		totalMerged := 0
+		resumedGroups := 0
+		remainingGroups := 0
+		sessionExpired := false
+		stoppedEarly := false
 
-		// Create reader for interactive input (only if not --auto)
+		// Create reader for interactive input (only if not --auto); with --batch and --tui every
+		// decision is made before the first merge, so the loop below does not prompt
+		prompting := !auto && !tuiMode && !batchMode
 		var reader *bufio.Reader
 		if !auto {
 			reader = bufio.NewReader(os.Stdin)
 		}
+		modeLabel := "[AUTO MODE]"
+		switch {
+		case tuiMode:
+			modeLabel = "[TUI]"
+		case batchMode:
+			modeLabel = "[BATCH]"
+		}
 
 		keys := make([]string, 0, len(duplicateGroups))
 		for groupKey := range duplicateGroups {
@@ -77,17 +186,174 @@ automatically or with your confirmation.`,
 		}
 		sort.Strings(keys)
 
-		// Loop through duplicate groups in deterministic order
+		// Fetch full item details for every group member; list summaries lack fields.
+		// Groups skipped in the run being resumed are never shown, so they are not fetched.
+		hydrateKeys := make([]string, 0, len(keys))
 		for _, groupKey := range keys {
+			if status, ok := cp.Status(groupKey); !resume || !ok || status != checkpoint.StatusSkipped {
+				hydrateKeys = append(hydrateKeys, groupKey)
+			}
+		}
+		fmt.Fprintln(stdout, "Fetching item details...")
+		duplicateGroups, hydrationErrs := hydrateGroups(duplicateGroups, hydrateKeys, concurrency)
+		for _, err := range hydrationErrs {
+			if op.IsAuthExpired(err) {
+				fmt.Fprintf(os.Stderr, "Error fetching item details: %v\n", err)
+				return
+			}
+		}
+
+		// With --batch and --tui every decision is made and confirmed up front, then applied below
+		var planned map[string]plannedDecision
+		if batchMode || tuiMode {
+			confirmed := false
+			if tuiMode {
+				planned, confirmed, err = reviewGroups(hydrateKeys, duplicateGroups)
+			} else {
+				planned, err = collectDecisions(reader, hydrateKeys, duplicateGroups)
+				if err == nil {
+					confirmed, err = confirmDecisions(reader, hydrateKeys, duplicateGroups, planned)
+				}
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return
+			}
+			if !confirmed {
+				fmt.Fprintln(stdout, "Review closed without applying any decisions.")
+				return
+			}
+			newlyIgnored := 0
+			for groupKey, decision := range planned {
+				if decision.action == planIgnore {
+					ignored.Add(groupKey)
+					newlyIgnored++
+				}
+			}
+			if newlyIgnored > 0 && !dryRun {
+				if err := ignored.Save(); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				}
+			}
+		}
+
+		// handleResult reports a finished group and records its outcome; results arrive in group order
+		handleResult := func(result groupResult) {
+			writeResult(stdout, os.Stderr, result)
+			if result.err != nil {
+				emit(events.TypeMergeFailed, mergeFailedEvent(result.key, result.err))
+				failedGroups++
+				recordGroup(cp, result.key, checkpoint.StatusFailed, dryRun)
+				// An expired session fails every remaining group, so stop cleanly instead
+				if op.IsAuthExpired(result.err) {
+					sessionExpired = true
+				}
+				return
+			}
+			emit(events.TypeMergeApplied, events.MergeApplied{Key: result.key, WinnerID: result.winnerID, LoserIDs: result.loserIDs, DryRun: dryRun})
+			processedGroups++
+			totalMerged += result.merged
+			recordGroup(cp, result.key, checkpoint.StatusProcessed, dryRun)
+		}
+
+		// Merges run on a bounded worker pool; prompts and output stay in group order
+		pool := workpool.New[groupResult](concurrency)
+
+		// Loop through duplicate groups in deterministic order
+		for i, groupKey := range keys {
+			for _, result := range pool.Ready() {
+				handleResult(result)
+			}
+			if sessionExpired {
+				remainingGroups += len(keys) - i
+				stoppedEarly = true
+				break
+			}
+
+			// When resuming, skip groups the user already declined and re-check merged ones:
+			// a merged group that still shows up here was not fully resolved.
+			if status, ok := cp.Status(groupKey); ok && resume {
+				switch status {
+				case checkpoint.StatusSkipped:
+					emit(events.TypeDecision, events.Decision{Key: groupKey, Decision: events.DecisionSkip, Reason: "checkpoint"})
+					resumedGroups++
+					continue
+				case checkpoint.StatusProcessed:
+					fmt.Fprintf(stdout, "\nGroup %s was merged in a previous run but still has duplicates; processing it again.\n", groupKey)
+				}
+			}
+
+			// Groups left unanswered when --batch was quit stay out of the checkpoint for --resume
+			if decision, ok := planned[groupKey]; ok && decision.action == planLater {
+				remainingGroups++
+				stoppedEarly = true
+				continue
+			}
+
+			if err, ok := hydrationErrs[groupKey]; ok {
+				fmt.Fprintf(os.Stderr, "Error fetching item details for group %s: %v\n", groupKey, err)
+				emit(events.TypeMergeFailed, mergeFailedEvent(groupKey, err))
+				failedGroups++
+				recordGroup(cp, groupKey, checkpoint.StatusFailed, dryRun)
+				continue
+			}
 			groupItems := duplicateGroups[groupKey]
-			displayDuplicateGroup(groupKey, groupItems)
+
+			// Groups where several items hold passkeys cannot be merged without losing one
+			if _, err := items.SelectWinnerForMerge(groupItems); errors.Is(err, items.ErrMultiplePasskeys) {
+				// Let earlier groups finish first so output stays in group order
+				for pool.Pending() > 0 {
+					result, _ := pool.Next()
+					handleResult(result)
+				}
+				displayDuplicateGroup(groupKey, groupItems)
+				emit(events.TypeGroupFound, groupFoundEvent(groupKey, groupItems))
+				emit(events.TypeDecision, events.Decision{Key: groupKey, Decision: events.DecisionSkip, Reason: "passkeys"})
+				fmt.Fprintln(stdout, "Skipped: resolve the passkeys in 1Password before merging this group.")
+				skippedGroups++
+				recordGroup(cp, groupKey, checkpoint.StatusSkipped, dryRun)
+				continue
+			}
+
+			// Without prompts the whole group report is buffered so parallel groups don't interleave
+			groupOut := &bytes.Buffer{}
+			if prompting {
+				displayDuplicateGroup(groupKey, groupItems)
+			} else {
+				writeDuplicateGroup(groupOut, groupKey, groupItems)
+			}
+
+			emit(events.TypeGroupFound, groupFoundEvent(groupKey, groupItems))
 
 			shouldMerge := false
+			winnerID := ""
+			groupPolicy := mergePolicy
 
-			// Handle auto mode vs interactive mode
+			// Handle auto mode, decisions made up front and interactive mode
 			if auto {
-				fmt.Println("[AUTO MODE] Merging group automatically...")
+				fmt.Fprintln(groupOut, "[AUTO MODE] Merging group automatically...")
+				emit(events.TypeDecision, events.Decision{Key: groupKey, Decision: events.DecisionMerge, Reason: "auto"})
+				shouldMerge = true
+				writeDefaultChoices(groupOut, modeLabel, groupItems)
+			} else if planned != nil {
+				decision, ok := planned[groupKey]
+				if !ok {
+					decision.reason = "unanswered"
+				}
+				if decision.action != planMerge {
+					emit(events.TypeDecision, events.Decision{Key: groupKey, Decision: events.DecisionSkip, Reason: decision.reason})
+					skippedGroups++
+					recordGroup(cp, groupKey, checkpoint.StatusSkipped, dryRun)
+					continue
+				}
+				emit(events.TypeDecision, events.Decision{Key: groupKey, Decision: events.DecisionMerge, Reason: decision.reason})
 				shouldMerge = true
+				winnerID = decision.winnerID
+				if decision.policy != nil {
+					groupPolicy = decision.policy
+				} else {
+					writeDefaultChoices(groupOut, modeLabel, groupItems)
+				}
 			} else {
 				response, err := promptUser(reader)
 				if err != nil {
@@ -96,82 +362,163 @@ automatically or with your confirmation.`,
 				}
 
 				if response == "q" {
-					fmt.Println("Exiting...")
+					emit(events.TypeDecision, events.Decision{Key: groupKey, Decision: events.DecisionQuit, Reason: "user"})
+					fmt.Fprintln(stdout, "Exiting...")
+					stoppedEarly = true
 					break
 				}
 
 				if response == "n" {
+					emit(events.TypeDecision, events.Decision{Key: groupKey, Decision: events.DecisionSkip, Reason: "user"})
 					skippedGroups++
-					fmt.Println("Skipped.")
+					recordGroup(cp, groupKey, checkpoint.StatusSkipped, dryRun)
+					fmt.Fprintln(stdout, "Skipped.")
 					continue
 				}
 
 				if response == "y" {
+					groupPolicy, err = promptGroupPolicy(reader, groupItems)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+						emit(events.TypeMergeFailed, mergeFailedEvent(groupKey, fmt.Errorf("failed to read input: %w", err)))
+						failedGroups++
+						recordGroup(cp, groupKey, checkpoint.StatusFailed, dryRun)
+						continue
+					}
+					emit(events.TypeDecision, events.Decision{Key: groupKey, Decision: events.DecisionMerge, Reason: "user"})
 					shouldMerge = true
 				}
 			}
 
-			// Process merge if confirmed
+			// Process merge if confirmed, waiting for a free worker once all are busy
 			if shouldMerge {
-				// Select winner (most recent item)
-				winner := items.SelectWinner(groupItems)
-
-				// Build losers slice (all items except winner)
-				losers := []models.Item{}
-				for _, item := range groupItems {
-					if item.ID != winner.ID {
-						losers = append(losers, item)
-					}
+				pool.Submit(func() groupResult {
+					return mergeGroup(groupKey, groupItems, winnerID, groupPolicy, groupOut)
+				})
+				for pool.Pending() >= concurrency {
+					result, _ := pool.Next()
+					handleResult(result)
 				}
+			}
+		}
 
-				// Iteratively merge all losers into winner
-				merged := winner
-				mergeSuccess := true
-				for _, loser := range losers {
-					var mergeErr error
-					merged, mergeErr = items.CalculateMerge(merged, loser)
-					if mergeErr != nil {
-						fmt.Fprintf(os.Stderr, "Error merging items: %v\n", mergeErr)
-						mergeSuccess = false
-						failedGroups++
-						break
-					}
-				}
+		// Wait for merges still in flight
+		for pool.Pending() > 0 {
+			result, _ := pool.Next()
+			handleResult(result)
+		}
 
-				if !mergeSuccess {
-					continue
-				}
+		emit(events.TypeSummary, events.Summary{
+			Items:             len(fetchedItems),
+			Groups:            len(keys),
+			Processed:         processedGroups,
+			Skipped:           skippedGroups,
+			Failed:            failedGroups,
+			Merged:            totalMerged,
+			PreviouslySkipped: resumedGroups,
+			Ignored:           ignoredGroups,
+			Remaining:         remainingGroups,
+			StoppedEarly:      stoppedEarly,
+		})
 
-				// Apply merge using existing helper
-				if err := applyMergeAndReport(os.Stdout, merged, losers, dryRun); err != nil {
-					fmt.Fprintf(os.Stderr, "Error applying merge: %v\n", err)
-					failedGroups++
-					continue
-				}
+		// Print summary
+		fmt.Fprintln(stdout, "\n=== Summary ===")
+		fmt.Fprintf(stdout, "Processed groups: %d\n", processedGroups)
+		fmt.Fprintf(stdout, "Skipped groups: %d\n", skippedGroups)
+		fmt.Fprintf(stdout, "Failed groups: %d\n", failedGroups)
+		fmt.Fprintf(stdout, "Total items merged: %d\n", totalMerged)
+		if resumedGroups > 0 {
+			fmt.Fprintf(stdout, "Previously skipped groups: %d\n", resumedGroups)
+		}
+		if ignoredGroups > 0 {
+			fmt.Fprintf(stdout, "Ignored groups: %d\n", ignoredGroups)
+		}
+		if remainingGroups > 0 {
+			fmt.Fprintf(stdout, "Stopped early: %d groups were not processed\n", remainingGroups)
+		}
+		if sessionExpired {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", op.ErrSessionExpired)
+		}
 
-				processedGroups++
-				totalMerged += len(losers)
+		// Keep the checkpoint only while there is something left to resume
+		if !dryRun {
+			if stoppedEarly {
+				fmt.Fprintln(stdout, "Run '1merge --resume' to continue where this run stopped.")
+			} else if err := cp.Clear(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 			}
 		}
-
-		// Print summary
-		fmt.Println("\n=== Summary ===")
-		fmt.Printf("Processed groups: %d\n", processedGroups)
-		fmt.Printf("Skipped groups: %d\n", skippedGroups)
-		fmt.Printf("Failed groups: %d\n", failedGroups)
-		fmt.Printf("Total items merged: %d\n", totalMerged)
 		if dryRun {
-			fmt.Println("(Dry run - no changes were made)")
+			fmt.Fprintln(stdout, "(Dry run - no changes were made)")
 		}
 	},
 }
 
+// loadMergePolicy builds mergePolicy from --policy and the flags that adjust it.
+func loadMergePolicy() error {
+	if policyPath != "" {
+		policy, err := items.LoadPolicy(policyPath)
+		if err != nil {
+			return err
+		}
+		mergePolicy = policy
+	} else {
+		policy, err := appConfig.MergePolicy()
+		if err != nil {
+			return err
+		}
+		mergePolicy = policy
+	}
+	if pwHistory {
+		mergePolicy = mergePolicy.WithLabelRule("password", items.ActionHistory)
+	}
+	if otpMode != "" {
+		mode, err := items.ParseOTPMode(otpMode)
+		if err != nil {
+			return err
+		}
+		mergePolicy = mergePolicy.WithOTP(mode, "")
+	}
+	if titleMode != "" {
+		strategy, err := items.ParseTitleStrategy(titleMode)
+		if err != nil {
+			return err
+		}
+		mergePolicy = mergePolicy.WithTitle(strategy, "")
+	}
+	if foldWWW {
+		mergePolicy = mergePolicy.WithFoldWWW()
+	}
+	return nil
+}
+
 func Execute() error {
 	return rootCmd.Execute()
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Path of the config file (default $XDG_CONFIG_HOME/1merge/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Named profile from the config file to apply")
 	rootCmd.PersistentFlags().StringVar(&vault, "vault", "", "Specifies which 1Password vault to scan (uses default vault if not specified)")
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Prevents any write operations and only prints what would happen")
 	rootCmd.PersistentFlags().BoolVar(&auto, "auto", false, "Automatically merges duplicates without prompting")
+	rootCmd.PersistentFlags().BoolVar(&verify, "verify", true, "Re-reads each edited item and only archives duplicates if every merged field and URL is present")
+	rootCmd.PersistentFlags().IntVar(&concurrency, "concurrency", 1, "Number of op commands to run in parallel when fetching item details and applying merges")
+	rootCmd.PersistentFlags().StringVar(&policyPath, "policy", "", "Path to a JSON merge policy deciding how conflicting fields are resolved")
+	rootCmd.PersistentFlags().BoolVar(&pwHistory, "password-history", false, "Records differing loser passwords as dated entries in a \"Previous Passwords\" section instead of Archived Conflicts")
+	rootCmd.PersistentFlags().BoolVar(&tagMerged, "tag-merged", false, "Adds the \"1merge/merged\" tag to every item that absorbed duplicates")
+	rootCmd.PersistentFlags().StringVar(&otpMode, "otp", "", "Which one-time password stays active when duplicates have different secrets: winner (default), newest or ask")
+	rootCmd.PersistentFlags().StringVar(&titleMode, "title", "", "How the merged item's title is chosen: winner (default), shortest, common, domain or ask")
+	rootCmd.PersistentFlags().BoolVar(&mergeHistory, "merge-history", false, "Adds a \"Merge History\" section listing the absorbed items and labels fields added from them with their source")
+	rootCmd.PersistentFlags().BoolVar(&foldWWW, "fold-www", false, "Treats \"www.example.com\" and \"example.com\" as the same URL when combining URLs")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", "text", "Output format: text, json (one document at the end) or ndjson (one event per line); events go to stdout and text to stderr")
+	rootCmd.PersistentFlags().BoolVar(&batchMode, "batch", false, "Asks about every group first, shows all answers for a final confirmation, then applies them")
+	rootCmd.PersistentFlags().BoolVar(&tuiMode, "tui", false, "Reviews all groups in a full-screen terminal UI and applies the queued decisions after a final confirmation")
+	rootCmd.PersistentFlags().StringSliceVar(&domainPatterns, "domain", nil, "Only processes groups whose base domain matches one of these comma-separated patterns, e.g. 'google.com,*.amazon.*'")
+	rootCmd.PersistentFlags().StringSliceVar(&excludePatterns, "exclude-domain", nil, "Skips groups whose base domain matches one of these comma-separated patterns")
+	rootCmd.PersistentFlags().StringVar(&olderThan, "older-than", "", "Only processes groups in which every item was last updated at least this long ago, e.g. 2y, 6m, 2w or 90d")
+	rootCmd.PersistentFlags().IntVar(&minGroupSize, "min-group-size", 2, "Only processes groups with at least this many items")
+	rootCmd.PersistentFlags().StringVar(&usernameRegex, "username-regex", "", "Only processes groups whose username matches this regular expression (ignoring case)")
+	rootCmd.PersistentFlags().StringSliceVar(&itemVaults, "item-vault", nil, "Only considers items stored in these comma-separated vaults (names or IDs); groups left with one item are dropped")
+	rootCmd.PersistentFlags().BoolVar(&resume, "resume", false, "Continues an interrupted run from its checkpoint, skipping groups already handled")
 }
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"1merge/internal/items"
+	"1merge/internal/models"
+)
+
+// groupResult is the outcome of merging one duplicate group, produced by a worker.
+type groupResult struct {
+	key string
+	// output is the group's buffered stdout, printed in group order.
+	output []byte
+	// errContext prefixes err in the user-facing message.
+	errContext string
+	err        error
+	merged     int
+	winnerID   string
+	loserIDs   []string
+}
+
+// mergeGroup merges the losers of a group into its winner using policy and applies the result.
+// winnerID picks the winner; when empty it is selected automatically.
+// Everything the merge prints goes to out, so workers can run groups concurrently.
+func mergeGroup(groupKey string, groupItems []models.Item, winnerID string, policy *items.MergePolicy, out *bytes.Buffer) groupResult {
+	result := groupResult{key: groupKey}
+
+	winner, err := selectWinner(groupItems, winnerID)
+	if err != nil {
+		result.output = out.Bytes()
+		result.errContext = "Error merging items"
+		result.err = err
+		return result
+	}
+
+	// Build losers slice (all items except winner)
+	losers := []models.Item{}
+	result.winnerID = winner.ID
+	for _, item := range groupItems {
+		if item.ID != winner.ID {
+			losers = append(losers, item)
+			result.loserIDs = append(result.loserIDs, item.ID)
+		}
+	}
+
+	// Merge all losers into winner at once, so the result does not depend on their order
+	mergeResult, err := items.MergeAll(winner, losers, policy)
+	if err != nil {
+		result.output = out.Bytes()
+		result.errContext = "Error merging items"
+		result.err = err
+		return result
+	}
+	merged := mergeResult.Item
+
+	merged.Title = items.ChooseTitle(groupItems, winner, policy)
+
+	if tagMerged {
+		merged = items.AddTag(merged, items.MergedTag)
+	}
+
+	// Apply merge using existing helper
+	opts := items.ApplyOptions{DryRun: dryRun, Out: out, Verify: verify, Rollback: true}
+	if mergeHistory {
+		opts.Provenance = &items.Provenance{RunID: runID, Sources: mergeResult.Sources}
+	}
+	if err := applyMergeAndReport(out, merged, losers, opts); err != nil {
+		result.output = out.Bytes()
+		result.errContext = "Error applying merge"
+		result.err = err
+		return result
+	}
+
+	result.output = out.Bytes()
+	result.merged = len(losers)
+	return result
+}
+
+// selectWinner returns the item with winnerID, or the automatic winner (passkey holder,
+// otherwise most recent item) when winnerID is empty. An item holding a passkey must win.
+func selectWinner(groupItems []models.Item, winnerID string) (models.Item, error) {
+	winner, err := items.SelectWinnerForMerge(groupItems)
+	if err != nil || winnerID == "" || winnerID == winner.ID {
+		return winner, err
+	}
+	if items.HasPasskey(winner) {
+		return models.Item{}, fmt.Errorf("item %s holds a passkey and must be the winner", winner.ID)
+	}
+	for _, item := range groupItems {
+		if item.ID == winnerID {
+			return item, nil
+		}
+	}
+	return models.Item{}, fmt.Errorf("winner %s is not in the group", winnerID)
+}
+
+// hydrateGroups fetches full item details for every member of every group, using up to
+// concurrency op processes at once. Groups with a member that could not be fetched are
+// left out of the returned map and reported in failed instead.
+func hydrateGroups(groups map[string][]models.Item, keys []string, concurrency int) (hydrated map[string][]models.Item, failed map[string]error) {
+	var summaries []models.Item
+	for _, key := range keys {
+		summaries = append(summaries, groups[key]...)
+	}
+
+	details, errs := items.HydrateItems(summaries, concurrency)
+
+	hydrated = make(map[string][]models.Item, len(groups))
+	failed = make(map[string]error)
+	next := 0
+	for _, key := range keys {
+		size := len(groups[key])
+		for i := next; i < next+size; i++ {
+			if errs[i] != nil {
+				failed[key] = errs[i]
+				break
+			}
+		}
+		if _, ok := failed[key]; !ok {
+			hydrated[key] = details[next : next+size]
+		}
+		next += size
+	}
+
+	return hydrated, failed
+}
+
+// writeResult prints a worker's buffered output followed by its error, if any.
+func writeResult(out, errOut io.Writer, result groupResult) {
+	out.Write(result.output)
+	if result.err != nil {
+		fmt.Fprintf(errOut, "%s: %v\n", result.errContext, result.err)
+	}
+}
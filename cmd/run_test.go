@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"1merge/internal/items"
+	"1merge/internal/models"
+	"1merge/internal/op"
+)
+
+// itemStoreClient answers "op item get" from an in-memory store and fails items listed in getErrs.
+type itemStoreClient struct {
+	mu      sync.Mutex
+	calls   []string
+	store   map[string]models.Item
+	getErrs map[string]error
+}
+
+func (c *itemStoreClient) RunOpCmd(args ...string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, strings.Join(args, " "))
+
+	if len(args) >= 3 && args[0] == "item" && args[1] == "get" {
+		if err, ok := c.getErrs[args[2]]; ok {
+			return nil, err
+		}
+		return json.Marshal(c.store[args[2]])
+	}
+	return nil, nil
+}
+
+func TestHydrateGroups(t *testing.T) {
+	client := &itemStoreClient{
+		store: map[string]models.Item{
+			"a1": {ID: "a1", Title: "A1 full", Fields: []models.Field{{Label: "password", Value: "x"}}},
+			"a2": {ID: "a2", Title: "A2 full"},
+			"b1": {ID: "b1", Title: "B1 full"},
+			"b2": {ID: "b2", Title: "B2 full"},
+		},
+		getErrs: map[string]error{"b2": errors.New("not found")},
+	}
+	items.SetOpClient(client)
+	t.Cleanup(func() { items.SetOpClient(op.DefaultClient) })
+
+	groups := map[string][]models.Item{
+		"a.com|user": {{ID: "a1", AdditionalInformation: "user"}, {ID: "a2", AdditionalInformation: "user"}},
+		"b.com|user": {{ID: "b1"}, {ID: "b2"}},
+	}
+
+	hydrated, failed := hydrateGroups(groups, []string{"a.com|user", "b.com|user"}, 4)
+
+	if len(failed) != 1 || failed["b.com|user"] == nil {
+		t.Fatalf("expected only group b.com|user to fail, got %v", failed)
+	}
+	if _, ok := hydrated["b.com|user"]; ok {
+		t.Fatal("failed group should not be returned as hydrated")
+	}
+
+	groupA := hydrated["a.com|user"]
+	if len(groupA) != 2 || groupA[0].Title != "A1 full" || groupA[1].Title != "A2 full" {
+		t.Fatalf("expected hydrated items in original order, got %+v", groupA)
+	}
+	if len(groupA[0].Fields) != 1 {
+		t.Fatalf("expected hydrated item to carry fields, got %+v", groupA[0])
+	}
+	if groupA[0].AdditionalInformation != "user" {
+		t.Fatalf("expected summary AdditionalInformation to be kept, got %q", groupA[0].AdditionalInformation)
+	}
+}
+
+func TestMergeGroup(t *testing.T) {
+	oldDryRun, oldVerify := dryRun, verify
+	t.Cleanup(func() { dryRun, verify = oldDryRun, oldVerify })
+	dryRun, verify = false, false
+
+	newer := models.Item{ID: "new", Title: "New", UpdatedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}
+	older := models.Item{ID: "old", Title: "Old", UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	t.Run("success", func(t *testing.T) {
+		client := &itemStoreClient{store: map[string]models.Item{"new": newer}}
+		items.SetOpClient(client)
+		t.Cleanup(func() { items.SetOpClient(op.DefaultClient) })
+
+		var out bytes.Buffer
+		result := mergeGroup("example.com|user", []models.Item{older, newer}, "", nil, &out)
+
+		if result.err != nil {
+			t.Fatalf("unexpected error: %v", result.err)
+		}
+		if result.merged != 1 {
+			t.Fatalf("expected 1 merged item, got %d", result.merged)
+		}
+		if !strings.Contains(string(result.output), "Successfully merged 1 items into new") {
+			t.Fatalf("expected success message in buffered output, got %q", result.output)
+		}
+	})
+
+	t.Run("failure is reported with context", func(t *testing.T) {
+		client := &itemStoreClient{getErrs: map[string]error{"new": errors.New("get failed")}}
+		items.SetOpClient(client)
+		t.Cleanup(func() { items.SetOpClient(op.DefaultClient) })
+
+		var out bytes.Buffer
+		result := mergeGroup("example.com|user", []models.Item{older, newer}, "", nil, &out)
+		if result.err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		var stdout, stderr bytes.Buffer
+		writeResult(&stdout, &stderr, result)
+		if !strings.HasPrefix(stderr.String(), "Error applying merge:") {
+			t.Fatalf("expected apply error on stderr, got %q", stderr.String())
+		}
+	})
+}
+
+func TestSelectWinner(t *testing.T) {
+	newer := models.Item{ID: "new", UpdatedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}
+	older := models.Item{ID: "old", UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	passkey := models.Item{ID: "pk", UpdatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		Fields: []models.Field{{Type: "PASSKEY", Label: "passkey"}}}
+
+	tests := []struct {
+		name     string
+		group    []models.Item
+		winnerID string
+		expected string
+		wantErr  bool
+	}{
+		{"automatic", []models.Item{older, newer}, "", "new", false},
+		{"chosen", []models.Item{older, newer}, "old", "old", false},
+		{"not in group", []models.Item{older, newer}, "other", "", true},
+		{"passkey holder must win", []models.Item{newer, passkey}, "new", "", true},
+		{"passkey holder chosen", []models.Item{newer, passkey}, "pk", "pk", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			winner, err := selectWinner(tt.group, tt.winnerID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectWinner error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && winner.ID != tt.expected {
+				t.Fatalf("selectWinner = %q, expected %q", winner.ID, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"1merge/internal/events"
+	"1merge/internal/items"
+	"1merge/internal/op"
+	"1merge/internal/stats"
+)
+
+var statsTop int
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show how many duplicates the vault has",
+	Long: `Stats scans the vault without changing anything and reports the total number of
+items, duplicate groups and redundant items, the domains with the most duplicates,
+the items that cannot be checked for duplicates because they have no URL or no
+username, the number of items per vault and how long ago items were last updated.
+With --output json or ndjson, the figures are written as a stats event instead.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cmd.SilenceUsage = true
+
+		if err := setupOutput(); err != nil {
+			return err
+		}
+		defer closeOutput()
+
+		if err := op.VerifyOpReady(); err != nil {
+			return err
+		}
+
+		fetchedItems, err := items.FetchItems(vault)
+		if err != nil {
+			return fmt.Errorf("failed to fetch items: %w", err)
+		}
+		duplicateGroups, err := filterGroups(items.GroupDuplicatesWithEquivalents(fetchedItems, appConfig.EquivalentDomains))
+		if err != nil {
+			return err
+		}
+
+		writeStats(stats.Build(fetchedItems, duplicateGroups, vault, statsTop, time.Now()))
+		return nil
+	},
+}
+
+// writeStats emits a stats event with --output json or ndjson and prints the tables otherwise.
+func writeStats(vaultStats stats.Stats) {
+	if eventOut.Enabled() {
+		emit(events.TypeStats, vaultStats)
+		return
+	}
+	stats.WriteText(stdout, vaultStats)
+}
+
+func init() {
+	statsCmd.Flags().IntVar(&statsTop, "top", 10, "Number of domains to list")
+	rootCmd.AddCommand(statsCmd)
+}
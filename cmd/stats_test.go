@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"1merge/internal/events"
+	"1merge/internal/stats"
+)
+
+func TestWriteStats_Events(t *testing.T) {
+	vaultStats := stats.Stats{TotalItems: 10, DuplicateGroups: 2, RedundantItems: 3}
+
+	for _, format := range structuredFormats {
+		t.Run(string(format), func(t *testing.T) {
+			written := captureEvents(t, format, func() { writeStats(vaultStats) })
+			if len(written) != 1 || written[0].Type != events.TypeStats {
+				t.Fatalf("expected one stats event, got %+v", written)
+			}
+			var decoded stats.Stats
+			if err := json.Unmarshal(written[0].Data, &decoded); err != nil {
+				t.Fatalf("invalid stats data: %v", err)
+			}
+			if decoded.TotalItems != 10 || decoded.RedundantItems != 3 {
+				t.Fatalf("unexpected stats: %+v", decoded)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"os"
+
+	"1merge/internal/models"
+	"1merge/internal/tui"
+)
+
+// reviewGroups shows the groups in keys in the full-screen review and returns the decisions the
+// user confirmed. confirmed is false when the user quit without applying.
+func reviewGroups(keys []string, groups map[string][]models.Item) (planned map[string]plannedDecision, confirmed bool, err error) {
+	review := make([]tui.Group, 0, len(keys))
+	for _, groupKey := range keys {
+		if groupItems, ok := groups[groupKey]; ok {
+			review = append(review, tui.Group{Key: groupKey, Items: groupItems})
+		}
+	}
+
+	model, err := tui.Run(os.Stdin, stdout, review)
+	if err != nil {
+		return nil, false, err
+	}
+	if !model.Confirmed() {
+		return nil, false, nil
+	}
+
+	planned = make(map[string]plannedDecision)
+	for groupKey, decision := range model.Decisions() {
+		switch decision.Action {
+		case tui.ActionMerge:
+			planned[groupKey] = plannedDecision{action: planMerge, winnerID: decision.WinnerID, reason: "tui"}
+		case tui.ActionIgnore:
+			planned[groupKey] = plannedDecision{action: planIgnore, reason: "ignored"}
+		case tui.ActionSkip:
+			planned[groupKey] = plannedDecision{action: planSkip, reason: "tui"}
+		}
+	}
+	return planned, true, nil
+}
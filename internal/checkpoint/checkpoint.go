@@ -0,0 +1,192 @@
+package checkpoint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Status records what happened to a duplicate group during a run.
+type Status string
+
+const (
+	// StatusProcessed means the group was merged successfully.
+	StatusProcessed Status = "processed"
+	// StatusSkipped means the user chose not to merge the group.
+	StatusSkipped Status = "skipped"
+	// StatusFailed means merging the group failed and it should be retried.
+	StatusFailed Status = "failed"
+)
+
+// Checkpoint tracks the duplicate groups handled so far in one vault, so an
+// interrupted run can be resumed without prompting for the same groups again.
+type Checkpoint struct {
+	Vault     string    `json:"vault"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Processed []string  `json:"processed"`
+	Skipped   []string  `json:"skipped"`
+	Failed    []string  `json:"failed"`
+
+	path     string
+	statuses map[string]Status
+}
+
+// New returns an empty checkpoint for the given vault ("" means the default vault).
+func New(vault string) (*Checkpoint, error) {
+	path, err := Path(vault)
+	if err != nil {
+		return nil, err
+	}
+	return &Checkpoint{
+		Vault:    vault,
+		path:     path,
+		statuses: make(map[string]Status),
+	}, nil
+}
+
+// Load reads the saved checkpoint for the given vault.
+// If no checkpoint exists, an empty one is returned.
+func Load(vault string) (*Checkpoint, error) {
+	cp, err := New(vault)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(cp.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %s: %w", cp.path, err)
+	}
+
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", cp.path, err)
+	}
+
+	for _, key := range cp.Processed {
+		cp.statuses[key] = StatusProcessed
+	}
+	for _, key := range cp.Skipped {
+		cp.statuses[key] = StatusSkipped
+	}
+	for _, key := range cp.Failed {
+		cp.statuses[key] = StatusFailed
+	}
+
+	return cp, nil
+}
+
+// Path returns the checkpoint file location for a vault: its name with unsafe characters
+// replaced, followed by a short hash of the exact name.
+// Checkpoints live under $XDG_STATE_HOME/1merge (default ~/.local/state/1merge).
+func Path(vault string) (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate home directory for checkpoint: %w", err)
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+
+	// The hash keeps vaults whose names only differ in unsafe characters apart, e.g. "a/b" and "a_b"
+	name := "default"
+	if vault != "" {
+		sum := sha256.Sum256([]byte(vault))
+		name = sanitizeFileName(vault) + "-" + hex.EncodeToString(sum[:4])
+	}
+
+	return filepath.Join(dir, "1merge", "checkpoint-"+name+".json"), nil
+}
+
+// Len returns the number of groups recorded in the checkpoint.
+func (c *Checkpoint) Len() int {
+	return len(c.statuses)
+}
+
+// Status returns the recorded status for a group key, if any.
+func (c *Checkpoint) Status(groupKey string) (Status, bool) {
+	status, ok := c.statuses[groupKey]
+	return status, ok
+}
+
+// Mark records the outcome of a group, replacing any earlier outcome.
+func (c *Checkpoint) Mark(groupKey string, status Status) {
+	c.statuses[groupKey] = status
+}
+
+// Save writes the checkpoint to disk atomically with owner-only permissions.
+func (c *Checkpoint) Save() error {
+	c.Processed, c.Skipped, c.Failed = []string{}, []string{}, []string{}
+	for key, status := range c.statuses {
+		switch status {
+		case StatusProcessed:
+			c.Processed = append(c.Processed, key)
+		case StatusSkipped:
+			c.Skipped = append(c.Skipped, key)
+		case StatusFailed:
+			c.Failed = append(c.Failed, key)
+		}
+	}
+	sort.Strings(c.Processed)
+	sort.Strings(c.Skipped)
+	sort.Strings(c.Failed)
+	c.UpdatedAt = time.Now().UTC()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+
+	// Write to a temp file and rename so a crash never leaves a truncated checkpoint
+	tempFile, err := os.CreateTemp(filepath.Dir(c.path), ".checkpoint-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint temp file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to close checkpoint temp file: %w", err)
+	}
+	if err := os.Rename(tempFile.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// Clear deletes the checkpoint file; a missing file is not an error.
+func (c *Checkpoint) Clear() error {
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+	return nil
+}
+
+// sanitizeFileName replaces characters that are unsafe in file names.
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
@@ -0,0 +1,114 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	stateDir := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", stateDir)
+
+	tests := []struct {
+		vault    string
+		expected string
+	}{
+		{"", filepath.Join(stateDir, "1merge", "checkpoint-default.json")},
+		{"Private", filepath.Join(stateDir, "1merge", "checkpoint-Private-c63eb672.json")},
+		{"Team/Shared Vault", filepath.Join(stateDir, "1merge", "checkpoint-Team_Shared_Vault-ba477dd2.json")},
+		{"Team_Shared Vault", filepath.Join(stateDir, "1merge", "checkpoint-Team_Shared_Vault-75f66e7c.json")},
+	}
+
+	for _, tt := range tests {
+		got, err := Path(tt.vault)
+		if err != nil {
+			t.Fatalf("Path(%q) returned error: %v", tt.vault, err)
+		}
+		if got != tt.expected {
+			t.Errorf("Path(%q) = %q, expected %q", tt.vault, got, tt.expected)
+		}
+	}
+}
+
+func TestLoad_MissingFileReturnsEmptyCheckpoint(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	cp, err := Load("Private")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cp.Len() != 0 {
+		t.Fatalf("expected empty checkpoint, got %d entries", cp.Len())
+	}
+}
+
+func TestSaveAndLoad_RoundTrip(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	cp, err := New("Private")
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	cp.Mark("google.com|user", StatusProcessed)
+	cp.Mark("amazon.com|user", StatusSkipped)
+	cp.Mark("github.com|user", StatusFailed)
+	cp.Mark("github.com|user", StatusProcessed) // later outcome replaces earlier one
+
+	if err := cp.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	info, err := os.Stat(cp.path)
+	if err != nil {
+		t.Fatalf("checkpoint file not written: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected checkpoint permissions 0600, got %v", info.Mode().Perm())
+	}
+
+	loaded, err := Load("Private")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	expected := map[string]Status{
+		"google.com|user": StatusProcessed,
+		"amazon.com|user": StatusSkipped,
+		"github.com|user": StatusProcessed,
+	}
+	if loaded.Len() != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), loaded.Len())
+	}
+	for key, want := range expected {
+		got, ok := loaded.Status(key)
+		if !ok || got != want {
+			t.Errorf("Status(%q) = %q, %v; expected %q", key, got, ok, want)
+		}
+	}
+	if len(loaded.Failed) != 0 {
+		t.Errorf("expected no failed groups, got %v", loaded.Failed)
+	}
+}
+
+func TestClear(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	cp, _ := New("")
+	cp.Mark("google.com|user", StatusSkipped)
+	if err := cp.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	if err := cp.Clear(); err != nil {
+		t.Fatalf("Clear returned error: %v", err)
+	}
+	if _, err := os.Stat(cp.path); !os.IsNotExist(err) {
+		t.Fatalf("expected checkpoint file to be removed, stat error: %v", err)
+	}
+
+	// Clearing twice is harmless
+	if err := cp.Clear(); err != nil {
+		t.Fatalf("second Clear returned error: %v", err)
+	}
+}
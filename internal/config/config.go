@@ -0,0 +1,154 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"1merge/internal/items"
+)
+
+// Config is the content of the config file. Defaults and profiles hold flag values by flag name,
+// e.g. "vault" or "older-than"; a profile's values take precedence over the defaults.
+type Config struct {
+	Defaults map[string]Value            `yaml:"defaults"`
+	Profiles map[string]map[string]Value `yaml:"profiles"`
+	// EquivalentDomains lists sets of base domains that belong to the same site.
+	EquivalentDomains [][]string `yaml:"equivalent_domains"`
+	// Ignore lists group keys (domain|username) that are never processed.
+	Ignore []string `yaml:"ignore"`
+	// Policy is a merge policy in the same shape as a --policy file.
+	Policy map[string]any `yaml:"policy"`
+
+	path  string
+	found bool
+}
+
+// Value is a setting from the config file as flag text. Lists are joined with commas, as they
+// would be written on the command line.
+type Value string
+
+// UnmarshalYAML accepts a scalar or a list of scalars.
+func (v *Value) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*v = Value(node.Value)
+		return nil
+	case yaml.SequenceNode:
+		parts := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: list entries must be plain values", item.Line)
+			}
+			parts = append(parts, item.Value)
+		}
+		*v = Value(strings.Join(parts, ","))
+		return nil
+	}
+	return fmt.Errorf("line %d: expected a value or a list of values", node.Line)
+}
+
+// Path returns the config file location: $XDG_CONFIG_HOME/1merge/config.yaml
+// (default ~/.config/1merge/config.yaml).
+func Path() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate home directory for config file: %w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "1merge", "config.yaml"), nil
+}
+
+// Load reads the config file at path. A missing file yields an empty config unless required is set.
+func Load(path string, required bool) (*Config, error) {
+	cfg := &Config{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	cfg.found = true
+	return cfg, nil
+}
+
+// Path returns the file the config was loaded from.
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Found reports whether the config file existed.
+func (c *Config) Found() bool {
+	return c.found
+}
+
+// Settings returns the flag values of a profile merged over the defaults. An empty profile
+// returns the defaults alone; an unknown profile is an error.
+func (c *Config) Settings(profile string) (map[string]Value, error) {
+	settings := make(map[string]Value, len(c.Defaults))
+	for name, value := range c.Defaults {
+		settings[name] = value
+	}
+	if profile == "" {
+		return settings, nil
+	}
+
+	values, ok := c.Profiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q in %s (available: %s)", profile, c.path, strings.Join(c.ProfileNames(), ", "))
+	}
+	for name, value := range values {
+		settings[name] = value
+	}
+	return settings, nil
+}
+
+// ProfileNames returns the names of the profiles in sorted order.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MergePolicy returns the policy from the config file, or nil if it has none.
+func (c *Config) MergePolicy() (*items.MergePolicy, error) {
+	if len(c.Policy) == 0 {
+		return nil, nil
+	}
+
+	// The policy has the same shape as a policy file, so it is decoded the same way
+	data, err := json.Marshal(c.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read merge policy in %s: %w", c.path, err)
+	}
+	var policy items.MergePolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse merge policy in %s: %w", c.path, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid merge policy in %s: %w", c.path, err)
+	}
+	return &policy, nil
+}
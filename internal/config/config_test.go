@@ -0,0 +1,123 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"1merge/internal/items"
+)
+
+const sample = `
+defaults:
+  vault: Private
+  concurrency: 4
+  domain: [google.com, "*.amazon.*"]
+profiles:
+  work:
+    vault: Work
+    tag-merged: true
+equivalent_domains:
+  - [google.com, youtube.com]
+ignore:
+  - "example.com|me"
+policy:
+  labels:
+    password: history
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	cfg, err := Load(writeConfig(t, sample), true)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if !cfg.Found() {
+		t.Fatal("expected the config file to be found")
+	}
+	if cfg.Defaults["domain"] != "google.com,*.amazon.*" {
+		t.Fatalf("lists should be joined with commas, got %q", cfg.Defaults["domain"])
+	}
+	if len(cfg.EquivalentDomains) != 1 || len(cfg.Ignore) != 1 {
+		t.Fatalf("unexpected equivalents %v or ignore list %v", cfg.EquivalentDomains, cfg.Ignore)
+	}
+
+	policy, err := cfg.MergePolicy()
+	if err != nil {
+		t.Fatalf("MergePolicy returned error: %v", err)
+	}
+	if policy.Labels["password"] != items.ActionHistory {
+		t.Fatalf("expected password history rule, got %+v", policy)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		required bool
+	}{
+		{"unknown section", "default:\n  vault: x\n", false},
+		{"nested value", "defaults:\n  vault: {name: x}\n", false},
+		{"not yaml", "defaults: [\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(writeConfig(t, tt.content), tt.required); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	if cfg, err := Load(missing, false); err != nil || cfg.Found() {
+		t.Fatalf("a missing optional file should load empty, got %v", err)
+	}
+	if _, err := Load(missing, true); err == nil {
+		t.Fatal("expected error for a missing required file")
+	}
+
+	cfg, err := Load(writeConfig(t, "policy:\n  default: shred\n"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.MergePolicy(); err == nil {
+		t.Fatal("expected error for an invalid policy")
+	}
+}
+
+func TestSettings(t *testing.T) {
+	cfg, err := Load(writeConfig(t, sample), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := cfg.Settings("work")
+	if err != nil {
+		t.Fatalf("Settings returned error: %v", err)
+	}
+	expected := map[string]Value{"vault": "Work", "concurrency": "4", "domain": "google.com,*.amazon.*", "tag-merged": "true"}
+	if len(settings) != len(expected) {
+		t.Fatalf("Settings = %v, expected %v", settings, expected)
+	}
+	for name, value := range expected {
+		if settings[name] != value {
+			t.Fatalf("%s = %q, expected %q", name, settings[name], value)
+		}
+	}
+
+	if defaults, _ := cfg.Settings(""); defaults["vault"] != "Private" {
+		t.Fatalf("expected defaults without a profile, got %v", defaults)
+	}
+	if _, err := cfg.Settings("home"); err == nil {
+		t.Fatal("expected error for unknown profile")
+	}
+}
@@ -0,0 +1,430 @@
+package doctor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"1merge/internal/checkpoint"
+	"1merge/internal/config"
+	"1merge/internal/ignore"
+	"1merge/internal/op"
+)
+
+// Status is the outcome of a check.
+type Status int
+
+const (
+	StatusPass Status = iota
+	// StatusWarn means the check could not confirm the setup, but a run may still work.
+	StatusWarn
+	StatusFail
+	// StatusSkip means the check needs something an earlier check found missing.
+	StatusSkip
+)
+
+// String returns the label shown in the checklist.
+func (s Status) String() string {
+	switch s {
+	case StatusWarn:
+		return "WARN"
+	case StatusFail:
+		return "FAIL"
+	case StatusSkip:
+		return "SKIP"
+	default:
+		return "PASS"
+	}
+}
+
+// Check is one line of the checklist.
+type Check struct {
+	Name   string
+	Status Status
+	Detail string
+	// Fix suggests how to resolve a warning or failure.
+	Fix string
+}
+
+// Supported op CLI versions: at least MinOpVersion and below MaxOpVersion.
+var (
+	MinOpVersion = Version{2, 20, 0}
+	MaxOpVersion = Version{3, 0, 0}
+)
+
+const installURL = "https://developer.1password.com/docs/cli/get-started/"
+
+// Options tells Run what to check.
+type Options struct {
+	Client op.Client
+	// LookPath finds the op binary, usually exec.LookPath.
+	LookPath func(file string) (string, error)
+	// Vault is the vault a run would scan; empty checks every vault.
+	Vault string
+	// Config is the loaded config file, or nil if loading failed with ConfigErr.
+	Config    *config.Config
+	ConfigErr error
+}
+
+// Run performs every check in order. Checks that need the op CLI are skipped once it is
+// missing or signed out.
+func Run(opts Options) []Check {
+	var checks []Check
+
+	installed := checkInstalled(opts.LookPath)
+	checks = append(checks, installed)
+	if installed.Status == StatusFail {
+		checks = append(checks, skipped("op version", "op CLI not installed"), skipped("Signed in", "op CLI not installed"))
+	} else {
+		checks = append(checks, checkVersion(opts.Client))
+		signedIn, userID := checkSignedIn(opts.Client)
+		checks = append(checks, signedIn)
+		if signedIn.Status == StatusFail {
+			checks = append(checks, skipped("Backend connectivity", "not signed in"))
+		} else {
+			checks = append(checks, checkVaults(opts.Client, opts.Vault, userID)...)
+		}
+	}
+
+	checks = append(checks, checkTempDir())
+	checks = append(checks, checkStateDir(opts.Vault))
+	checks = append(checks, checkIgnoreList())
+	checks = append(checks, checkConfig(opts.Config, opts.ConfigErr))
+	return checks
+}
+
+func skipped(name, reason string) Check {
+	return Check{Name: name, Status: StatusSkip, Detail: reason}
+}
+
+func checkInstalled(lookPath func(string) (string, error)) Check {
+	path, err := lookPath("op")
+	if err != nil {
+		return Check{Name: "op CLI installed", Status: StatusFail, Detail: "op not found in PATH", Fix: "Install the 1Password CLI from " + installURL}
+	}
+	return Check{Name: "op CLI installed", Status: StatusPass, Detail: path}
+}
+
+func checkVersion(client op.Client) Check {
+	check := Check{Name: "op version"}
+	supported := fmt.Sprintf("supported: >= %s, < %s", MinOpVersion, MaxOpVersion)
+
+	output, err := client.RunOpCmd("--version")
+	if err != nil {
+		check.Status, check.Detail, check.Fix = StatusFail, fmt.Sprintf("could not run op --version: %v", err), "Reinstall the 1Password CLI from "+installURL
+		return check
+	}
+	version, err := ParseVersion(string(output))
+	if err != nil {
+		check.Status, check.Detail = StatusWarn, fmt.Sprintf("%v (%s)", err, supported)
+		return check
+	}
+
+	check.Detail = fmt.Sprintf("%s (%s)", version, supported)
+	switch {
+	case version.Less(MinOpVersion):
+		check.Status, check.Fix = StatusFail, "Update the 1Password CLI: "+installURL
+	case !version.Less(MaxOpVersion):
+		check.Status, check.Fix = StatusWarn, fmt.Sprintf("This op version is newer than 1merge was tested with; if runs fail, install an op version below %s", MaxOpVersion)
+	}
+	return check
+}
+
+// checkSignedIn runs "op whoami" and returns the signed-in user's ID for the vault permission checks.
+func checkSignedIn(client op.Client) (Check, string) {
+	check := Check{Name: "Signed in"}
+	output, err := client.RunOpCmd("whoami", "--format", "json")
+	if err != nil {
+		check.Status, check.Detail = StatusFail, firstLine(err)
+		check.Fix = "Run 'eval $(op signin)', or turn on the 1Password app integration in Settings > Developer"
+		if op.IsRetryable(err) {
+			check.Fix = "1Password could not be reached; check your network connection and proxy settings"
+		}
+		return check, ""
+	}
+
+	var whoami struct {
+		URL    string `json:"url"`
+		Email  string `json:"email"`
+		UserID string `json:"user_uuid"`
+	}
+	if err := json.Unmarshal(output, &whoami); err != nil {
+		check.Status, check.Detail = StatusWarn, fmt.Sprintf("could not read op whoami output: %v", err)
+		return check, ""
+	}
+	check.Status, check.Detail = StatusPass, strings.TrimSpace(whoami.Email+" on "+whoami.URL)
+	return check, whoami.UserID
+}
+
+type vaultSummary struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// checkVaults lists the vaults, which also proves the 1Password servers can be reached, and checks
+// access to the vault a run would use, or to every vault when none is selected.
+func checkVaults(client op.Client, vault, userID string) []Check {
+	output, err := client.RunOpCmd("vault", "list", "--format", "json")
+	if err != nil {
+		fix := "Check your network connection and proxy settings, then try again"
+		if op.IsAuthExpired(err) {
+			fix = "Run 'eval $(op signin)' to start a new session"
+		}
+		return []Check{
+			{Name: "Backend connectivity", Status: StatusFail, Detail: firstLine(err), Fix: fix},
+			skipped("Vaults", "vault list unavailable"),
+		}
+	}
+
+	var vaults []vaultSummary
+	if err := json.Unmarshal(output, &vaults); err != nil {
+		return []Check{
+			{Name: "Backend connectivity", Status: StatusPass, Detail: "vault list received"},
+			{Name: "Vaults", Status: StatusWarn, Detail: fmt.Sprintf("could not read op vault list output: %v", err)},
+		}
+	}
+
+	names := make([]string, len(vaults))
+	for i, v := range vaults {
+		names[i] = v.Name
+	}
+	checks := []Check{
+		{Name: "Backend connectivity", Status: StatusPass, Detail: "vault list received"},
+		{Name: "Vaults", Status: StatusPass, Detail: fmt.Sprintf("%d available: %s", len(vaults), strings.Join(names, ", "))},
+	}
+
+	targets := vaults
+	if vault != "" {
+		targets = nil
+		for _, v := range vaults {
+			if v.ID == vault || strings.EqualFold(v.Name, vault) {
+				targets = append(targets, v)
+			}
+		}
+		if len(targets) == 0 {
+			checks[1].Status = StatusFail
+			checks[1].Fix = fmt.Sprintf("Vault %q was not found; pass one of the names above to --vault", vault)
+			return checks
+		}
+	}
+	for _, v := range targets {
+		checks = append(checks, checkVaultRead(client, v), checkVaultWrite(client, v, userID))
+	}
+	return checks
+}
+
+func checkVaultRead(client op.Client, v vaultSummary) Check {
+	check := Check{Name: fmt.Sprintf("Vault %q readable", v.Name)}
+	output, err := client.RunOpCmd("item", "list", "--vault", v.ID, "--categories", "LOGIN", "--format", "json")
+	if err != nil {
+		check.Status, check.Detail, check.Fix = StatusFail, firstLine(err), "Ask a vault manager for permission to view items in this vault"
+		return check
+	}
+	var logins []json.RawMessage
+	if err := json.Unmarshal(output, &logins); err != nil {
+		check.Status, check.Detail = StatusWarn, fmt.Sprintf("could not read op item list output: %v", err)
+		return check
+	}
+	check.Status, check.Detail = StatusPass, fmt.Sprintf("%d logins", len(logins))
+	return check
+}
+
+// editPermissions are the vault permissions that allow editing and archiving items, in business
+// accounts and in individual and family accounts.
+var editPermissions = []string{"allow_editing", "edit_items", "update_items"}
+
+func checkVaultWrite(client op.Client, v vaultSummary, userID string) Check {
+	check := Check{Name: fmt.Sprintf("Vault %q writable", v.Name)}
+	unknown := func(detail string) Check {
+		check.Status, check.Detail = StatusWarn, "could not determine edit permission: "+detail
+		check.Fix = "Make sure your account can edit and archive items in this vault before merging"
+		return check
+	}
+	if userID == "" {
+		return unknown("signed-in user unknown")
+	}
+
+	output, err := client.RunOpCmd("vault", "user", "list", v.ID, "--format", "json")
+	if err != nil {
+		return unknown(firstLine(err))
+	}
+	var users []struct {
+		ID          string   `json:"id"`
+		Permissions []string `json:"permissions"`
+	}
+	if err := json.Unmarshal(output, &users); err != nil {
+		return unknown(err.Error())
+	}
+
+	for _, user := range users {
+		if user.ID != userID {
+			continue
+		}
+		for _, permission := range user.Permissions {
+			for _, edit := range editPermissions {
+				if strings.EqualFold(permission, edit) {
+					check.Status, check.Detail = StatusPass, "can edit items"
+					return check
+				}
+			}
+		}
+		check.Status, check.Detail = StatusFail, "your account cannot edit items in this vault"
+		check.Fix = "Ask a vault manager for permission to edit items, or use --dry-run to preview merges"
+		return check
+	}
+	// Access may come through a group rather than a direct grant
+	return unknown("no direct permission listed for your account")
+}
+
+func checkTempDir() Check {
+	check := Check{Name: "Temp directory", Detail: os.TempDir()}
+	fix := "Set TMPDIR to a directory you can write to"
+
+	dir, err := os.MkdirTemp("", "1merge-doctor-*")
+	if err != nil {
+		check.Status, check.Detail, check.Fix = StatusFail, err.Error(), fix
+		return check
+	}
+	defer os.RemoveAll(dir)
+
+	// Merge templates hold secrets, so temp files must be private to the user
+	file, err := os.CreateTemp(dir, "check-*.json")
+	if err != nil {
+		check.Status, check.Detail, check.Fix = StatusFail, err.Error(), fix
+		return check
+	}
+	file.Close()
+	info, err := os.Stat(file.Name())
+	if err != nil {
+		check.Status, check.Detail, check.Fix = StatusFail, err.Error(), fix
+		return check
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		check.Status, check.Fix = StatusWarn, "Temp files are readable by other users; set TMPDIR to a private directory"
+		check.Detail = fmt.Sprintf("%s (temp files created with mode %s)", os.TempDir(), info.Mode().Perm())
+	}
+	return check
+}
+
+// checkStateDir checks that checkpoints can be written and points out an unfinished run.
+func checkStateDir(vault string) Check {
+	check := Check{Name: "Checkpoint directory"}
+	path, err := checkpoint.Path(vault)
+	if err != nil {
+		check.Status, check.Detail, check.Fix = StatusFail, err.Error(), "Set XDG_STATE_HOME to a writable directory"
+		return check
+	}
+	dir := filepath.Dir(path)
+	check.Detail = dir
+	if err := writable(dir); err != nil {
+		check.Status, check.Detail, check.Fix = StatusFail, err.Error(), "Make "+dir+" writable, or set XDG_STATE_HOME to a writable directory"
+		return check
+	}
+	if _, err := os.Stat(path); err == nil {
+		check.Status = StatusWarn
+		check.Detail = fmt.Sprintf("%s (an interrupted run left a checkpoint)", dir)
+		check.Fix = "Run '1merge --resume' to finish it; a new run without --resume starts over"
+	}
+	return check
+}
+
+func checkIgnoreList() Check {
+	check := Check{Name: "Ignore list"}
+	path, err := ignore.Path()
+	if err != nil {
+		check.Status, check.Detail = StatusFail, err.Error()
+		return check
+	}
+	list, err := ignore.Load()
+	if err != nil {
+		check.Status, check.Detail, check.Fix = StatusFail, firstLine(err), "Fix or delete "+path
+		return check
+	}
+	check.Detail = fmt.Sprintf("%s (%d groups)", path, list.Len())
+	return check
+}
+
+func checkConfig(cfg *config.Config, loadErr error) Check {
+	check := Check{Name: "Config file"}
+	if loadErr != nil {
+		check.Status, check.Detail, check.Fix = StatusFail, firstLine(loadErr), "Fix the config file; '1merge config show' lists the settings in effect"
+		return check
+	}
+	if cfg == nil || !cfg.Found() {
+		path := ""
+		if cfg != nil {
+			path = cfg.Path() + " "
+		}
+		check.Detail = path + "(not found, using built-in defaults)"
+		return check
+	}
+	if _, err := cfg.MergePolicy(); err != nil {
+		check.Status, check.Detail, check.Fix = StatusFail, err.Error(), "Fix the policy section of the config file"
+		return check
+	}
+	check.Detail = cfg.Path()
+	return check
+}
+
+// writable creates dir if needed and checks that a file can be created in it.
+func writable(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// firstLine returns the first line of an error, leaving out op's stderr dump.
+func firstLine(err error) string {
+	var opErr *op.Error
+	if errors.As(err, &opErr) && opErr.Stderr != "" {
+		return strings.TrimSpace(strings.SplitN(opErr.Stderr, "\n", 2)[0])
+	}
+	line, _, _ := strings.Cut(err.Error(), "\n")
+	return line
+}
+
+// Version is a semantic version of the op CLI.
+type Version struct {
+	Major, Minor, Patch int
+}
+
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)\.(\d+)`)
+
+// ParseVersion reads a version such as "2.30.0" or "2.31.0-beta.01" from op --version output.
+func ParseVersion(s string) (Version, error) {
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("unrecognized op version %q", strings.TrimSpace(s))
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	v.Patch, _ = strconv.Atoi(m[3])
+	return v, nil
+}
+
+// Less reports whether v is older than other.
+func (v Version) Less(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+	return v.Patch < other.Patch
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
@@ -0,0 +1,184 @@
+package doctor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"1merge/internal/config"
+	"1merge/internal/op"
+)
+
+// fakeClient answers op commands by their joined arguments.
+type fakeClient struct {
+	outputs map[string]string
+	errs    map[string]error
+}
+
+func (c *fakeClient) RunOpCmd(args ...string) ([]byte, error) {
+	key := strings.Join(args, " ")
+	if err, ok := c.errs[key]; ok {
+		return nil, err
+	}
+	if output, ok := c.outputs[key]; ok {
+		return []byte(output), nil
+	}
+	return nil, errors.New("unexpected command: " + key)
+}
+
+func healthyClient() *fakeClient {
+	return &fakeClient{outputs: map[string]string{
+		"--version":                "2.30.0\n",
+		"whoami --format json":     `{"url": "my.1password.com", "email": "me@example.com", "user_uuid": "U1"}`,
+		"vault list --format json": `[{"id": "v1", "name": "Private"}, {"id": "v2", "name": "Shared"}]`,
+		"item list --vault v1 --categories LOGIN --format json": `[{"id": "a"}, {"id": "b"}]`,
+		"item list --vault v2 --categories LOGIN --format json": `[]`,
+		"vault user list v1 --format json":                      `[{"id": "U1", "permissions": ["allow_viewing", "allow_editing"]}]`,
+		"vault user list v2 --format json":                      `[{"id": "U1", "permissions": ["allow_viewing"]}]`,
+	}}
+}
+
+func found(path string) (string, error) { return "/usr/bin/" + path, nil }
+
+func statuses(checks []Check) map[string]Status {
+	result := make(map[string]Status, len(checks))
+	for _, check := range checks {
+		result[check.Name] = check.Status
+	}
+	return result
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	tests := []struct {
+		name     string
+		client   func() *fakeClient
+		lookPath func(string) (string, error)
+		vault    string
+		expected map[string]Status
+	}{
+		{
+			name:     "healthy setup, read-only vault",
+			client:   healthyClient,
+			lookPath: found,
+			expected: map[string]Status{
+				"op CLI installed": StatusPass, "op version": StatusPass, "Signed in": StatusPass,
+				"Backend connectivity": StatusPass, "Vaults": StatusPass,
+				`Vault "Private" readable`: StatusPass, `Vault "Private" writable`: StatusPass,
+				`Vault "Shared" readable`: StatusPass, `Vault "Shared" writable`: StatusFail,
+				"Temp directory": StatusPass, "Checkpoint directory": StatusPass, "Ignore list": StatusPass, "Config file": StatusPass,
+			},
+		},
+		{
+			name:     "only the selected vault is checked",
+			client:   healthyClient,
+			lookPath: found,
+			vault:    "private",
+			expected: map[string]Status{`Vault "Private" writable`: StatusPass, `Vault "Shared" writable`: -1},
+		},
+		{
+			name:     "unknown vault",
+			client:   healthyClient,
+			lookPath: found,
+			vault:    "Work",
+			expected: map[string]Status{"Vaults": StatusFail, `Vault "Private" readable`: -1},
+		},
+		{
+			name:     "op missing",
+			client:   healthyClient,
+			lookPath: func(string) (string, error) { return "", errors.New("not found") },
+			expected: map[string]Status{"op CLI installed": StatusFail, "op version": StatusSkip, "Signed in": StatusSkip, "Vaults": -1},
+		},
+		{
+			name: "old version and signed out",
+			client: func() *fakeClient {
+				c := healthyClient()
+				c.outputs["--version"] = "2.10.1"
+				c.errs = map[string]error{"whoami --format json": &op.Error{Kind: op.KindAuthExpired, Stderr: "[ERROR] account is not signed in"}}
+				return c
+			},
+			lookPath: found,
+			expected: map[string]Status{"op version": StatusFail, "Signed in": StatusFail, "Backend connectivity": StatusSkip},
+		},
+		{
+			name: "servers unreachable",
+			client: func() *fakeClient {
+				c := healthyClient()
+				c.errs = map[string]error{"vault list --format json": &op.Error{Kind: op.KindRetryable, Stderr: "dial tcp: no such host"}}
+				return c
+			},
+			lookPath: found,
+			expected: map[string]Status{"Signed in": StatusPass, "Backend connectivity": StatusFail, "Vaults": StatusSkip},
+		},
+		{
+			name: "permissions not visible",
+			client: func() *fakeClient {
+				c := healthyClient()
+				c.errs = map[string]error{"vault user list v1 --format json": errors.New("forbidden")}
+				return c
+			},
+			lookPath: found,
+			expected: map[string]Status{`Vault "Private" writable`: StatusWarn},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := Run(Options{Client: tt.client(), LookPath: tt.lookPath, Vault: tt.vault, Config: &config.Config{}})
+			got := statuses(checks)
+			for name, want := range tt.expected {
+				status, ok := got[name]
+				if want == -1 {
+					if ok {
+						t.Fatalf("check %q should not run", name)
+					}
+					continue
+				}
+				if !ok || status != want {
+					t.Fatalf("check %q = %v (present %v), expected %v; all checks: %+v", name, status, ok, want, checks)
+				}
+			}
+			for _, check := range checks {
+				if (check.Status == StatusFail) && check.Fix == "" {
+					t.Fatalf("failed check %q has no suggested fix", check.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestRun_ConfigError(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	checks := Run(Options{
+		Client:    healthyClient(),
+		LookPath:  found,
+		Config:    &config.Config{},
+		ConfigErr: errors.New("failed to parse config file"),
+	})
+	if got := statuses(checks)["Config file"]; got != StatusFail {
+		t.Fatalf("Config file = %v, expected FAIL", got)
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Version
+		wantErr  bool
+	}{
+		{"2.30.0\n", Version{2, 30, 0}, false},
+		{"2.31.0-beta.01", Version{2, 31, 0}, false},
+		{"garbage", Version{}, true},
+	}
+	for _, tt := range tests {
+		v, err := ParseVersion(tt.input)
+		if (err != nil) != tt.wantErr || v != tt.expected {
+			t.Fatalf("ParseVersion(%q) = %v, %v; expected %v", tt.input, v, err, tt.expected)
+		}
+	}
+
+	if !(Version{2, 9, 9}).Less(Version{2, 10, 0}) || (Version{3, 0, 0}).Less(Version{2, 99, 99}) {
+		t.Fatal("Less compares versions numerically")
+	}
+}
@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// CanonicalOptions controls the optional rewrites done by CanonicalURL.
+type CanonicalOptions struct {
+	// FoldWWW treats "www.example.com" and "example.com" as the same host.
+	FoldWWW bool
+}
+
+// trackingParams lists query parameters that only identify where a visit came from.
+// Parameters starting with "utm_" are always treated as tracking parameters.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_ga":     true,
+	"ref":     true,
+	"ref_src": true,
+}
+
+// defaultPorts maps schemes to the port that is implied when none is given.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// CanonicalURL returns a normalized form of a URL for comparing saved website addresses.
+// Two URLs with the same canonical form open the same page. For example, all of these
+// return "https://example.com/":
+//   - "example.com" (scheme defaults to https)
+//   - "https://EXAMPLE.com:443" (case and default ports are ignored)
+//   - "https://example.com/?utm_source=mail#top" (tracking parameters and fragments are dropped)
+//
+// Trailing slashes on paths are removed, and remaining query parameters are sorted.
+// With opts.FoldWWW, a leading "www." on the host is removed as well.
+//
+// If the URL is invalid or has no hostname, an error is returned.
+func CanonicalURL(urlStr string, opts CanonicalOptions) (string, error) {
+	urlStr = strings.TrimSpace(urlStr)
+	if urlStr == "" {
+		return "", errors.New("empty URL string")
+	}
+
+	// Add scheme if missing so url.Parse identifies the hostname
+	if !strings.Contains(urlStr, "://") {
+		urlStr = "https://" + urlStr
+	}
+
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse URL: %w", err)
+	}
+
+	scheme := strings.ToLower(parsedURL.Scheme)
+	host := strings.TrimSuffix(strings.ToLower(parsedURL.Hostname()), ".")
+	if host == "" {
+		return "", errors.New("invalid URL: no hostname found")
+	}
+	if opts.FoldWWW {
+		host = strings.TrimPrefix(host, "www.")
+	}
+	if port := parsedURL.Port(); port != "" && port != defaultPorts[scheme] {
+		host += ":" + port
+	}
+
+	path := strings.TrimRight(parsedURL.EscapedPath(), "/")
+	if path == "" {
+		path = "/"
+	}
+
+	canonical := scheme + "://" + host + path
+	if query := canonicalQuery(parsedURL.Query()); query != "" {
+		canonical += "?" + query
+	}
+	return canonical, nil
+}
+
+// canonicalQuery encodes the non-tracking parameters of a query in sorted order.
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if !IsTrackingParam(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		for _, value := range values[key] {
+			parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// IsTrackingParam reports whether a query parameter is ignored by CanonicalURL.
+func IsTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasPrefix(name, "utm_") || trackingParams[name]
+}
+
+// MoreSpecificURL reports whether a is a more specific way of writing the same address than b,
+// so that a should be kept when both have the same canonical form. An explicit scheme beats
+// none, fewer tracking parameters beat more, and otherwise the longer form (which keeps
+// details such as "www." or a trailing slash) wins.
+func MoreSpecificURL(a string, b string) bool {
+	aScheme, bScheme := strings.Contains(a, "://"), strings.Contains(b, "://")
+	if aScheme != bScheme {
+		return aScheme
+	}
+
+	aTracking, bTracking := countTrackingParams(a), countTrackingParams(b)
+	if aTracking != bTracking {
+		return aTracking < bTracking
+	}
+
+	return len(strings.TrimSpace(a)) > len(strings.TrimSpace(b))
+}
+
+// countTrackingParams returns the number of tracking parameters in a URL's query.
+func countTrackingParams(urlStr string) int {
+	_, query, found := strings.Cut(urlStr, "?")
+	if !found {
+		return 0
+	}
+	query, _, _ = strings.Cut(query, "#")
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return 0
+	}
+
+	count := 0
+	for key, vals := range values {
+		if IsTrackingParam(key) {
+			count += len(vals)
+		}
+	}
+	return count
+}
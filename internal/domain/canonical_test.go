@@ -0,0 +1,66 @@
+package domain
+
+import "testing"
+
+func TestCanonicalURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		opts        CanonicalOptions
+		expected    string
+		expectError bool
+	}{
+		{name: "missing scheme", input: "example.com", expected: "https://example.com/"},
+		{name: "no path", input: "https://example.com", expected: "https://example.com/"},
+		{name: "trailing slash", input: "https://example.com/login/", expected: "https://example.com/login"},
+		{name: "case folding", input: "HTTPS://Example.COM/Login", expected: "https://example.com/Login"},
+		{name: "default https port", input: "https://example.com:443/", expected: "https://example.com/"},
+		{name: "default http port", input: "http://example.com:80", expected: "http://example.com/"},
+		{name: "non-default port kept", input: "https://example.com:8443", expected: "https://example.com:8443/"},
+		{name: "http kept distinct", input: "http://example.com", expected: "http://example.com/"},
+		{name: "tracking params removed", input: "https://example.com/login?utm_source=mail&ref=x&fbclid=1", expected: "https://example.com/login"},
+		{name: "other params sorted", input: "https://example.com/?b=2&utm_medium=x&a=1", expected: "https://example.com/?a=1&b=2"},
+		{name: "fragment dropped", input: "https://example.com/#top", expected: "https://example.com/"},
+		{name: "www kept by default", input: "https://www.example.com", expected: "https://www.example.com/"},
+		{name: "www folded", input: "http://www.example.com/login?ref=x", opts: CanonicalOptions{FoldWWW: true}, expected: "http://example.com/login"},
+		{name: "empty", input: "  ", expectError: true},
+		{name: "no hostname", input: "https://", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CanonicalURL(tt.input, tt.opts)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CanonicalURL(%q) returned error: %v", tt.input, err)
+			}
+			if got != tt.expected {
+				t.Errorf("CanonicalURL(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMoreSpecificURL(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"https://example.com", "example.com", true},
+		{"example.com", "https://example.com", false},
+		{"https://example.com/", "https://example.com", true},
+		{"https://example.com/login", "https://example.com/login?utm_source=x", true},
+		{"https://www.example.com", "https://example.com", true},
+	}
+
+	for _, tt := range tests {
+		if got := MoreSpecificURL(tt.a, tt.b); got != tt.expected {
+			t.Errorf("MoreSpecificURL(%q, %q) = %v, expected %v", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SchemaVersion is the version of the event schema. It changes only when a field is removed
+// or changes meaning; new event types and fields may be added without a version change.
+const SchemaVersion = 1
+
+// Format selects how a command reports what it does.
+type Format string
+
+const (
+	// FormatText prints human-readable text (the default); no events are written.
+	FormatText Format = "text"
+	// FormatJSON writes a single JSON document holding every event when the command finishes.
+	FormatJSON Format = "json"
+	// FormatNDJSON writes each event as one JSON line as soon as it happens.
+	FormatNDJSON Format = "ndjson"
+)
+
+// ParseFormat validates a format name; the empty string means FormatText.
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(strings.ToLower(name)); format {
+	case "":
+		return FormatText, nil
+	case FormatText, FormatJSON, FormatNDJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown output format %q (expected text, json or ndjson)", name)
+	}
+}
+
+// Type names an event.
+type Type string
+
+const (
+	// TypeScanStarted is emitted once before the vault is read; Data is ScanStarted.
+	TypeScanStarted Type = "scan_started"
+	// TypeGroupFound is emitted for each duplicate group before it is decided; Data is GroupFound.
+	TypeGroupFound Type = "group_found"
+	// TypeDecision records whether a group is merged or skipped; Data is Decision.
+	TypeDecision Type = "decision"
+	// TypeMergeApplied is emitted when a group was merged (or would be, in a dry run); Data is MergeApplied.
+	TypeMergeApplied Type = "merge_applied"
+	// TypeMergeFailed is emitted when a group could not be merged; Data is MergeFailed.
+	TypeMergeFailed Type = "merge_failed"
+	// TypeSummary is emitted once at the end of a run; Data is Summary.
+	TypeSummary Type = "summary"
+	// TypeReportWritten is emitted by "report" once the HTML page is written; Data is ReportWritten.
+	TypeReportWritten Type = "report_written"
+	// TypeStats is emitted once by "stats"; Data is the statistics built by package stats.
+	TypeStats Type = "stats"
+	// TypeDoctorCheck is emitted by "doctor" for each check; Data is DoctorCheck.
+	TypeDoctorCheck Type = "doctor_check"
+	// TypeConfig is emitted by "config show"; Data is Config.
+	TypeConfig Type = "config"
+)
+
+// Event is the envelope shared by all events.
+type Event struct {
+	SchemaVersion int       `json:"schema_version"`
+	Type          Type      `json:"type"`
+	Time          time.Time `json:"time"`
+	Data          any       `json:"data"`
+}
+
+// ScanStarted describes the run that is starting.
+type ScanStarted struct {
+	Vault  string `json:"vault"`
+	DryRun bool   `json:"dry_run"`
+	Auto   bool   `json:"auto"`
+	RunID  string `json:"run_id,omitempty"`
+}
+
+// GroupFound describes a duplicate group. It never contains field values.
+type GroupFound struct {
+	Key      string `json:"key"`
+	Domain   string `json:"domain"`
+	Username string `json:"username"`
+	Items    []Item `json:"items"`
+}
+
+// Item describes a group member without any secrets.
+type Item struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Vault       string    `json:"vault"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	URL         string    `json:"url,omitempty"`
+	Attachments int       `json:"attachments"`
+	HasOTP      bool      `json:"has_otp"`
+	HasPasskey  bool      `json:"has_passkey"`
+}
+
+// Decision values.
+const (
+	DecisionMerge = "merge"
+	DecisionSkip  = "skip"
+	DecisionQuit  = "quit"
+)
+
+// Decision records what happens to a group and why.
+type Decision struct {
+	Key      string `json:"key"`
+	Decision string `json:"decision"`
+	// Reason is "user", "auto", "checkpoint" (skipped in the run being resumed) or "passkeys"
+	// (several items hold passkeys).
+	Reason string `json:"reason"`
+}
+
+// MergeApplied describes a merged group.
+type MergeApplied struct {
+	Key      string   `json:"key"`
+	WinnerID string   `json:"winner_id"`
+	LoserIDs []string `json:"loser_ids"`
+	DryRun   bool     `json:"dry_run"`
+}
+
+// MergeFailed describes a group that could not be merged.
+type MergeFailed struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+	// State is the state the vault was left in, when known: "unchanged", "rolled back" or "partially merged".
+	State string `json:"state,omitempty"`
+	// NotArchived lists duplicates left in the vault when the winner kept the merged data.
+	NotArchived []string `json:"not_archived,omitempty"`
+}
+
+// Summary totals a run.
+type Summary struct {
+	Items             int  `json:"items"`
+	Groups            int  `json:"groups"`
+	Processed         int  `json:"processed"`
+	Skipped           int  `json:"skipped"`
+	Failed            int  `json:"failed"`
+	Merged            int  `json:"merged"`
+	PreviouslySkipped int  `json:"previously_skipped"`
+	Ignored           int  `json:"ignored"`
+	Remaining         int  `json:"remaining"`
+	StoppedEarly      bool `json:"stopped_early"`
+}
+
+// ReportWritten describes the HTML page written by "report".
+type ReportWritten struct {
+	Path   string `json:"path"`
+	Items  int    `json:"items"`
+	Groups int    `json:"groups"`
+	// Unreadable counts the groups whose item details could not be read.
+	Unreadable int `json:"unreadable"`
+}
+
+// DoctorCheck is the outcome of one "doctor" check.
+type DoctorCheck struct {
+	Name string `json:"name"`
+	// Status is "pass", "warn", "fail" or "skip".
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Fix    string `json:"fix,omitempty"`
+}
+
+// Config describes the effective configuration shown by "config show".
+type Config struct {
+	Path              string     `json:"path"`
+	Found             bool       `json:"found"`
+	Profile           string     `json:"profile,omitempty"`
+	Profiles          []string   `json:"profiles"`
+	Settings          []Setting  `json:"settings"`
+	EquivalentDomains [][]string `json:"equivalent_domains"`
+	IgnoredGroups     int        `json:"ignored_groups"`
+	Policy            string     `json:"policy"`
+}
+
+// Setting is a flag value and where it came from: "flag", "environment ONEMERGE_...",
+// "profile ...", "config defaults" or "default".
+type Setting struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// document is the output of FormatJSON.
+type document struct {
+	SchemaVersion int     `json:"schema_version"`
+	Events        []Event `json:"events"`
+}
+
+// Writer writes events in the chosen format. A nil Writer or one using FormatText ignores
+// events, so callers can emit unconditionally. It is safe for concurrent use.
+type Writer struct {
+	out    io.Writer
+	format Format
+
+	mu     sync.Mutex
+	events []Event
+
+	// now is overridden in tests.
+	now func() time.Time
+}
+
+// NewWriter returns a Writer that writes events to out in the given format.
+func NewWriter(out io.Writer, format Format) *Writer {
+	return &Writer{out: out, format: format, now: time.Now}
+}
+
+// Enabled reports whether events are written, i.e. the format is not FormatText.
+func (w *Writer) Enabled() bool {
+	return w != nil && w.format != FormatText
+}
+
+// Emit records an event. With FormatNDJSON it is written immediately.
+func (w *Writer) Emit(eventType Type, data any) error {
+	if !w.Enabled() {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	event := Event{SchemaVersion: SchemaVersion, Type: eventType, Time: w.now().UTC(), Data: data}
+	if w.format == FormatJSON {
+		w.events = append(w.events, event)
+		return nil
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	if _, err := w.out.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s event: %w", eventType, err)
+	}
+	return nil
+}
+
+// Close writes the collected events with FormatJSON; other formats have nothing left to write.
+func (w *Writer) Close() error {
+	if !w.Enabled() || w.format != FormatJSON {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	events := w.events
+	if events == nil {
+		events = []Event{}
+	}
+	data, err := json.MarshalIndent(document{SchemaVersion: SchemaVersion, Events: events}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode events: %w", err)
+	}
+	if _, err := w.out.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write events: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fixedClock() time.Time {
+	return time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    Format
+		expectError bool
+	}{
+		{"", FormatText, false},
+		{"text", FormatText, false},
+		{"JSON", FormatJSON, false},
+		{"ndjson", FormatNDJSON, false},
+		{"yaml", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.input)
+		if (err != nil) != tt.expectError || got != tt.expected {
+			t.Errorf("ParseFormat(%q) = %q, %v; expected %q (error: %v)", tt.input, got, err, tt.expected, tt.expectError)
+		}
+	}
+}
+
+func TestWriter_NDJSON(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, FormatNDJSON)
+	w.now = fixedClock
+
+	if err := w.Emit(TypeDecision, Decision{Key: "example.com|me", Decision: DecisionSkip, Reason: "user"}); err != nil {
+		t.Fatalf("Emit returned error: %v", err)
+	}
+	if err := w.Emit(TypeSummary, Summary{Groups: 1, Skipped: 1}); err != nil {
+		t.Fatalf("Emit returned error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", out.String())
+	}
+	expected := `{"schema_version":1,"type":"decision","time":"2024-01-15T09:30:00Z","data":{"key":"example.com|me","decision":"skip","reason":"user"}}`
+	if lines[0] != expected {
+		t.Errorf("line 1 = %s\nexpected %s", lines[0], expected)
+	}
+}
+
+func TestWriter_JSON(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, FormatJSON)
+	w.now = fixedClock
+
+	_ = w.Emit(TypeScanStarted, ScanStarted{Vault: "Private"})
+	if out.Len() != 0 {
+		t.Fatalf("JSON format should write nothing before Close, got %q", out.String())
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	var doc struct {
+		SchemaVersion int `json:"schema_version"`
+		Events        []struct {
+			Type Type `json:"type"`
+		} `json:"events"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out.String())
+	}
+	if doc.SchemaVersion != SchemaVersion || len(doc.Events) != 1 || doc.Events[0].Type != TypeScanStarted {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+}
+
+func TestWriter_TextAndNilIgnoreEvents(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, FormatText)
+	_ = w.Emit(TypeSummary, Summary{})
+	_ = w.Close()
+	if out.Len() != 0 {
+		t.Fatalf("text format should not write events, got %q", out.String())
+	}
+
+	var nilWriter *Writer
+	if nilWriter.Enabled() || nilWriter.Emit(TypeSummary, Summary{}) != nil || nilWriter.Close() != nil {
+		t.Fatal("nil writer should ignore events")
+	}
+}
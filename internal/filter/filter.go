@@ -0,0 +1,180 @@
+package filter
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"1merge/internal/models"
+)
+
+// Filter limits a run to part of the vault. It is applied to the groups returned by
+// items.GroupDuplicates; the zero value keeps every group.
+type Filter struct {
+	// Domains keeps only groups whose base domain matches one of these patterns.
+	Domains []string
+	// ExcludeDomains drops groups whose base domain matches one of these patterns.
+	ExcludeDomains []string
+	// OlderThan keeps only groups in which every item was last updated at least this long ago.
+	OlderThan Age
+	// MinSize keeps only groups with at least this many items.
+	MinSize int
+	// Username keeps only groups whose username matches.
+	Username *regexp.Regexp
+	// Vaults keeps only the items stored in one of these vaults (by name or ID), before groups
+	// are sized; a group left with fewer than two items is dropped.
+	Vaults []string
+}
+
+// Validate reports invalid domain patterns or sizes.
+func (f *Filter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Domains...), f.ExcludeDomains...) {
+		if _, err := path.Match(normalizePattern(pattern), ""); err != nil {
+			return fmt.Errorf("invalid domain pattern %q: %w", pattern, err)
+		}
+	}
+	if f.MinSize < 0 {
+		return fmt.Errorf("minimum group size must not be negative, got %d", f.MinSize)
+	}
+	return nil
+}
+
+// Apply returns the groups that pass the filter, measuring ages from now. groups is not modified.
+func (f *Filter) Apply(groups map[string][]models.Item, now time.Time) map[string][]models.Item {
+	minSize := max(f.MinSize, 2)
+	cutoff := f.OlderThan.Cutoff(now)
+
+	kept := make(map[string][]models.Item)
+	for key, groupItems := range groups {
+		if len(f.Vaults) > 0 {
+			groupItems = f.inVaults(groupItems)
+		}
+		if len(groupItems) < minSize {
+			continue
+		}
+
+		domain, username, _ := strings.Cut(key, "|")
+		if len(f.Domains) > 0 && !MatchDomain(f.Domains, domain) {
+			continue
+		}
+		if MatchDomain(f.ExcludeDomains, domain) {
+			continue
+		}
+		if f.Username != nil && !f.Username.MatchString(username) {
+			continue
+		}
+		if !f.OlderThan.IsZero() && !updatedBefore(groupItems, cutoff) {
+			continue
+		}
+		kept[key] = groupItems
+	}
+	return kept
+}
+
+// inVaults returns the items stored in one of f.Vaults.
+func (f *Filter) inVaults(groupItems []models.Item) []models.Item {
+	var kept []models.Item
+	for _, item := range groupItems {
+		for _, vault := range f.Vaults {
+			if strings.EqualFold(item.Vault.Name, vault) || item.Vault.ID == vault {
+				kept = append(kept, item)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// updatedBefore reports whether every item was last updated before cutoff.
+func updatedBefore(groupItems []models.Item, cutoff time.Time) bool {
+	for _, item := range groupItems {
+		if !item.UpdatedAt.Before(cutoff) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchDomain reports whether domain matches one of the glob patterns, ignoring case. "*" matches
+// any run of characters, so "amazon.*" matches "amazon.com" and "amazon.co.uk". A leading "*."
+// also matches the domain itself, so "*.amazon.*" matches "amazon.com" as well as "smile.amazon.com".
+func MatchDomain(patterns []string, domain string) bool {
+	domain = strings.ToLower(domain)
+	for _, pattern := range patterns {
+		pattern = normalizePattern(pattern)
+		if ok, _ := path.Match(pattern, domain); ok {
+			return true
+		}
+		if rest, found := strings.CutPrefix(pattern, "*."); found {
+			if ok, _ := path.Match(rest, domain); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalizePattern lowercases a domain pattern and trims surrounding spaces.
+func normalizePattern(pattern string) string {
+	return strings.ToLower(strings.TrimSpace(pattern))
+}
+
+// Age is a calendar duration such as "2y" or "6m", counted in years, months and days so that
+// "1y" always means one calendar year.
+type Age struct {
+	Years  int
+	Months int
+	Days   int
+}
+
+var agePart = regexp.MustCompile(`(\d+)([ymwd])`)
+
+// ParseAge parses an age made of a number and a unit: y (years), m (months), w (weeks) or d (days).
+// Parts can be combined, e.g. "1y6m".
+func ParseAge(s string) (Age, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	var age Age
+	if s == "" {
+		return age, nil
+	}
+
+	matches := agePart.FindAllStringSubmatchIndex(s, -1)
+	end := 0
+	for _, m := range matches {
+		if m[0] != end {
+			break
+		}
+		end = m[1]
+		n, err := strconv.Atoi(s[m[2]:m[3]])
+		if err != nil {
+			return Age{}, fmt.Errorf("invalid age %q: %w", s, err)
+		}
+		switch s[m[4]:m[5]] {
+		case "y":
+			age.Years += n
+		case "m":
+			age.Months += n
+		case "w":
+			age.Days += 7 * n
+		case "d":
+			age.Days += n
+		}
+	}
+	if end != len(s) || len(matches) == 0 {
+		return Age{}, fmt.Errorf("invalid age %q: expected a number followed by y, m, w or d, e.g. 2y or 6m", s)
+	}
+	return age, nil
+}
+
+// IsZero reports whether the age is empty.
+func (a Age) IsZero() bool {
+	return a == Age{}
+}
+
+// Cutoff returns the time that lies the age before now.
+func (a Age) Cutoff(now time.Time) time.Time {
+	return now.AddDate(-a.Years, -a.Months, -a.Days)
+}
@@ -0,0 +1,129 @@
+package filter
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"1merge/internal/models"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Age
+		wantErr  bool
+	}{
+		{"2y", Age{Years: 2}, false},
+		{"6m", Age{Months: 6}, false},
+		{"2w", Age{Days: 14}, false},
+		{"90d", Age{Days: 90}, false},
+		{"1y6m", Age{Years: 1, Months: 6}, false},
+		{" 3Y ", Age{Years: 3}, false},
+		{"", Age{}, false},
+		{"2", Age{}, true},
+		{"y", Age{}, true},
+		{"2x", Age{}, true},
+		{"2y junk", Age{}, true},
+	}
+
+	for _, tt := range tests {
+		age, err := ParseAge(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseAge(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if age != tt.expected {
+			t.Fatalf("ParseAge(%q) = %+v, expected %+v", tt.input, age, tt.expected)
+		}
+	}
+}
+
+func TestMatchDomain(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		domain   string
+		expected bool
+	}{
+		{[]string{"google.com"}, "google.com", true},
+		{[]string{"google.com"}, "google.de", false},
+		{[]string{"*.amazon.*"}, "amazon.com", true},
+		{[]string{"*.amazon.*"}, "amazon.co.uk", true},
+		{[]string{"*.amazon.*"}, "notamazon.com", false},
+		{[]string{"amazon.*"}, "amazon.de", true},
+		{[]string{"Google.COM"}, "google.com", true},
+		{[]string{"github.com", "google.*"}, "google.de", true},
+		{nil, "google.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchDomain(tt.patterns, tt.domain); got != tt.expected {
+			t.Fatalf("MatchDomain(%q, %q) = %v, expected %v", tt.patterns, tt.domain, got, tt.expected)
+		}
+	}
+}
+
+func TestFilter_Apply(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.AddDate(-3, 0, 0)
+	recent := now.AddDate(0, -1, 0)
+	item := func(id, vault string, updated time.Time) models.Item {
+		return models.Item{ID: id, Vault: models.Vault{ID: "id-" + strings.ToLower(vault), Name: vault}, UpdatedAt: updated}
+	}
+
+	groups := map[string][]models.Item{
+		"google.com|me":     {item("g1", "Private", old), item("g2", "Private", old)},
+		"amazon.co.uk|me":   {item("a1", "Private", old), item("a2", "Work", recent), item("a3", "Work", old)},
+		"github.com|work":   {item("h1", "Work", recent), item("h2", "Work", recent)},
+		"example.com|admin": {item("e1", "Private", old), item("e2", "Work", old)},
+	}
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected []string
+	}{
+		{"zero value keeps everything", Filter{}, []string{"amazon.co.uk|me", "example.com|admin", "github.com|work", "google.com|me"}},
+		{"domains", Filter{Domains: []string{"google.com", "*.amazon.*"}}, []string{"amazon.co.uk|me", "google.com|me"}},
+		{"exclude domains", Filter{ExcludeDomains: []string{"*.amazon.*", "example.com"}}, []string{"github.com|work", "google.com|me"}},
+		{"older than", Filter{OlderThan: Age{Years: 2}}, []string{"example.com|admin", "google.com|me"}},
+		{"min size", Filter{MinSize: 3}, []string{"amazon.co.uk|me"}},
+		{"username", Filter{Username: regexp.MustCompile("^(me|admin)$")}, []string{"amazon.co.uk|me", "example.com|admin", "google.com|me"}},
+		// example.com is left with a single Work item, so it is no longer a duplicate group
+		{"item vaults", Filter{Vaults: []string{"work"}}, []string{"amazon.co.uk|me", "github.com|work"}},
+		{"vault by ID", Filter{Vaults: []string{"id-private"}}, []string{"google.com|me"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept := tt.filter.Apply(groups, now)
+			keys := make([]string, 0, len(kept))
+			for key := range kept {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			if strings.Join(keys, ",") != strings.Join(tt.expected, ",") {
+				t.Fatalf("Apply kept %v, expected %v", keys, tt.expected)
+			}
+		})
+	}
+
+	// Filtering by vault leaves only the matching items in a group
+	kept := (&Filter{Vaults: []string{"Work"}}).Apply(groups, now)
+	if len(kept["amazon.co.uk|me"]) != 2 || len(groups["amazon.co.uk|me"]) != 3 {
+		t.Fatalf("expected 2 Work items kept and the input untouched, got %d and %d", len(kept["amazon.co.uk|me"]), len(groups["amazon.co.uk|me"]))
+	}
+}
+
+func TestFilter_Validate(t *testing.T) {
+	if err := (&Filter{Domains: []string{"[google.com"}}).Validate(); err == nil {
+		t.Fatal("expected error for malformed pattern")
+	}
+	if err := (&Filter{MinSize: -1}).Validate(); err == nil {
+		t.Fatal("expected error for negative size")
+	}
+	if err := (&Filter{Domains: []string{"*.amazon.*"}}).Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
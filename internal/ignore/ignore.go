@@ -0,0 +1,111 @@
+package ignore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// List holds the duplicate groups the user never wants to see again, by group key.
+// Unlike a checkpoint it is kept across runs and applies to every vault.
+type List struct {
+	Groups []string `json:"groups"`
+
+	path string
+	keys map[string]bool
+}
+
+// Path returns the ignore list location: $XDG_STATE_HOME/1merge/ignored.json
+// (default ~/.local/state/1merge/ignored.json).
+func Path() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate home directory for ignore list: %w", err)
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "1merge", "ignored.json"), nil
+}
+
+// Load reads the ignore list. If none exists, an empty list is returned.
+func Load() (*List, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	list := &List{path: path, keys: make(map[string]bool)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return list, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ignore list %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, list); err != nil {
+		return nil, fmt.Errorf("failed to parse ignore list %s: %w", path, err)
+	}
+	for _, key := range list.Groups {
+		list.keys[key] = true
+	}
+	return list, nil
+}
+
+// Contains reports whether a group is ignored; a nil list ignores nothing.
+func (l *List) Contains(groupKey string) bool {
+	return l != nil && l.keys[groupKey]
+}
+
+// Len returns the number of ignored groups.
+func (l *List) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.keys)
+}
+
+// Add ignores a group from now on. Call Save to persist the change.
+func (l *List) Add(groupKey string) {
+	l.keys[groupKey] = true
+}
+
+// Save writes the ignore list to disk atomically with owner-only permissions.
+func (l *List) Save() error {
+	l.Groups = make([]string, 0, len(l.keys))
+	for key := range l.keys {
+		l.Groups = append(l.Groups, key)
+	}
+	sort.Strings(l.Groups)
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal ignore list: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create ignore list directory: %w", err)
+	}
+
+	// Write to a temp file and rename so a crash never leaves a truncated list
+	tempFile, err := os.CreateTemp(filepath.Dir(l.path), ".ignored-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create ignore list temp file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("failed to write ignore list: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to close ignore list temp file: %w", err)
+	}
+	if err := os.Rename(tempFile.Name(), l.path); err != nil {
+		return fmt.Errorf("failed to save ignore list: %w", err)
+	}
+	return nil
+}
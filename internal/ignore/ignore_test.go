@@ -0,0 +1,47 @@
+package ignore
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLoadAddSave(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	list, err := Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if list.Len() != 0 || list.Contains("google.com|me") {
+		t.Fatal("expected an empty list when no file exists")
+	}
+
+	list.Add("google.com|me")
+	list.Add("amazon.com|me")
+	if err := list.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	info, err := os.Stat(list.path)
+	if err != nil {
+		t.Fatalf("ignore list not written: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected permissions 0600, got %v", info.Mode().Perm())
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if loaded.Len() != 2 || !loaded.Contains("google.com|me") || loaded.Contains("github.com|me") {
+		t.Fatalf("unexpected list after reload: %v", loaded.Groups)
+	}
+}
+
+func TestNilListIgnoresNothing(t *testing.T) {
+	var list *List
+	if list.Contains("google.com|me") || list.Len() != 0 {
+		t.Fatal("nil list should ignore nothing")
+	}
+}
@@ -3,6 +3,7 @@ package items
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 
 	"1merge/internal/models"
@@ -21,10 +22,37 @@ func SetOpClient(client op.Client) {
 	opClient = client
 }
 
+// ApplyOptions controls the optional steps of ApplyMergeWithOptions.
+type ApplyOptions struct {
+	// DryRun prints what would be changed without executing any op commands.
+	DryRun bool
+	// Out receives the dry-run report; nil means os.Stdout.
+	Out io.Writer
+	// Verify re-reads the winner after the edit and refuses to archive the losers
+	// if anything from the computed merge is missing (see VerifyMerge).
+	Verify bool
+	// Rollback undoes a failed apply: the winner is snapshotted before the edit and restored on
+	// any later failure, unless losers were already archived (see ApplyError.NotArchived).
+	Rollback bool
+	// Provenance, if set, adds a "Merge History" section to the winner and labels every
+	// field added from a loser with its source (see addProvenance).
+	Provenance *Provenance
+}
+
 // ApplyMerge orchestrates the actual 1Password vault modifications.
 // It updates the winner item with merged data and archives all loser items.
 // If dryRun is true, it prints what would be changed without executing any op commands.
 func ApplyMerge(winner models.Item, losers []models.Item, dryRun bool) error {
+	return ApplyMergeWithOptions(winner, losers, ApplyOptions{DryRun: dryRun})
+}
+
+// ApplyMergeWithOptions is ApplyMerge with optional safety steps controlled by opts.
+// With opts.Rollback set, every error is an *ApplyError describing the state the vault was left in.
+func ApplyMergeWithOptions(winner models.Item, losers []models.Item, opts ApplyOptions) error {
+	if opts.Provenance != nil {
+		winner = addProvenance(winner, losers, *opts.Provenance)
+	}
+
 	// Marshal winner to JSON
 	jsonBytes, err := json.MarshalIndent(winner, "", "  ")
 	if err != nil {
@@ -32,15 +60,86 @@ func ApplyMerge(winner models.Item, losers []models.Item, dryRun bool) error {
 	}
 
 	// Handle dry-run mode
-	if dryRun {
-		fmt.Printf("[DRY RUN] Would edit item: %s (%s)\n", winner.ID, winner.Title)
-		fmt.Println(string(jsonBytes))
+	if opts.DryRun {
+		out := opts.Out
+		if out == nil {
+			out = os.Stdout
+		}
+		fmt.Fprintf(out, "[DRY RUN] Would edit item: %s (%s)\n", winner.ID, winner.Title)
+		fmt.Fprintln(out, string(jsonBytes))
+		if opts.Verify {
+			fmt.Fprintf(out, "[DRY RUN] Would verify item: %s before archiving\n", winner.ID)
+		}
+		for _, transfer := range planAttachments(winner, losers) {
+			fmt.Fprintf(out, "[DRY RUN] Would copy attachment %q from item %s\n", transfer.name, transfer.loser.ID)
+		}
 		for _, loser := range losers {
-			fmt.Printf("[DRY RUN] Would archive item: %s (%s)\n", loser.ID, loser.Title)
+			fmt.Fprintf(out, "[DRY RUN] Would archive item: %s (%s)\n", loser.ID, loser.Title)
 		}
 		return nil
 	}
 
+	tx := &mergeTransaction{winnerID: winner.ID}
+	for _, loser := range losers {
+		tx.loserIDs = append(tx.loserIDs, loser.ID)
+	}
+
+	// Snapshot the winner so a later failure can put it back
+	if opts.Rollback {
+		original, err := GetItem(winner.ID)
+		if err != nil {
+			return tx.fail(fmt.Errorf("failed to snapshot item %s: %w", winner.ID, err), true)
+		}
+		tx.original = &original
+	}
+
+	// Execute edit command using template file
+	if err := editItem(winner.ID, jsonBytes); err != nil {
+		return tx.fail(err, opts.Rollback)
+	}
+	tx.edited = true
+
+	// Copy loser attachments, which archiving would otherwise hide with the loser
+	transfers := planAttachments(winner, losers)
+	if len(transfers) > 0 {
+		err := copyAttachments(winner.ID, transfers, func(name string) {
+			tx.attached = append(tx.attached, name)
+		})
+		if err != nil {
+			return tx.fail(err, opts.Rollback)
+		}
+	}
+
+	// Read the winner back and make sure the edit and every attachment landed before losers
+	// are archived. Attachments are always checked, since losing one cannot be undone.
+	if opts.Verify || len(transfers) > 0 {
+		stored, err := GetItem(winner.ID)
+		if err != nil {
+			return tx.fail(fmt.Errorf("failed to verify item %s: %w", winner.ID, err), opts.Rollback)
+		}
+		var differences []string
+		if opts.Verify {
+			differences = VerifyMerge(winner, stored)
+		}
+		differences = append(differences, missingAttachments(stored, transfers)...)
+		if len(differences) > 0 {
+			return tx.fail(&VerificationError{ItemID: winner.ID, Differences: differences}, opts.Rollback)
+		}
+	}
+
+	// Execute archive commands
+	for _, loser := range losers {
+		if _, err := opClient.RunOpCmd("item", "delete", loser.ID, "--archive"); err != nil {
+			return tx.fail(fmt.Errorf("failed to archive item %s: %w", loser.ID, err), opts.Rollback)
+		}
+		tx.archived = append(tx.archived, loser.ID)
+	}
+
+	return nil
+}
+
+// editItem replaces an item's contents with the given JSON template via "op item edit".
+func editItem(id string, jsonBytes []byte) error {
 	// Create temp file for item JSON template
 	tempFile, err := os.CreateTemp("", "1merge-*.json")
 	if err != nil {
@@ -57,16 +156,8 @@ func ApplyMerge(winner models.Item, losers []models.Item, dryRun bool) error {
 		return fmt.Errorf("failed to close temp file: %w", err)
 	}
 
-	// Execute edit command using template file
-	if _, err := opClient.RunOpCmd("item", "edit", winner.ID, "--template", tempFile.Name()); err != nil {
-		return fmt.Errorf("failed to edit item %s: %w", winner.ID, err)
-	}
-
-	// Execute archive commands
-	for _, loser := range losers {
-		if _, err := opClient.RunOpCmd("item", "delete", loser.ID, "--archive"); err != nil {
-			return fmt.Errorf("failed to archive item %s: %w", loser.ID, err)
-		}
+	if _, err := opClient.RunOpCmd("item", "edit", id, "--template", tempFile.Name()); err != nil {
+		return fmt.Errorf("failed to edit item %s: %w", id, err)
 	}
 
 	return nil
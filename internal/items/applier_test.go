@@ -2,6 +2,7 @@ package items
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -59,6 +60,8 @@ type fakeOpClient struct {
 	editErr error
 	// archiveErr is used to simulate errors specifically for archive (delete) operations
 	archiveErr error
+	// getOutput is returned for item get operations
+	getOutput []byte
 }
 
 func (f *fakeOpClient) RunOpCmd(args ...string) ([]byte, error) {
@@ -71,6 +74,9 @@ func (f *fakeOpClient) RunOpCmd(args ...string) ([]byte, error) {
 	if len(args) >= 2 && args[0] == "item" && args[1] == "delete" && f.archiveErr != nil {
 		return nil, f.archiveErr
 	}
+	if len(args) >= 2 && args[0] == "item" && args[1] == "get" {
+		return f.getOutput, nil
+	}
 	return nil, nil
 }
 
@@ -509,3 +515,71 @@ func TestApplyMerge_OutputFormat(t *testing.T) {
 		t.Errorf("ApplyMerge() produced no output in dry-run mode")
 	}
 }
+
+func TestApplyMergeWithOptions_Verify(t *testing.T) {
+	winner := createTestItem("winner1", "Winner Item", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC))
+	losers := []models.Item{
+		createTestItem("loser1", "Loser Item 1", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+	}
+
+	mustMarshal := func(item models.Item) []byte {
+		data, err := json.Marshal(item)
+		if err != nil {
+			t.Fatalf("failed to marshal item: %v", err)
+		}
+		return data
+	}
+
+	missingPassword := winner
+	missingPassword.Fields = winner.Fields[:1]
+
+	tests := []struct {
+		name              string
+		stored            []byte
+		expectedCallCount int
+		expectErr         bool
+	}{
+		{
+			name:              "matching item archives losers",
+			stored:            mustMarshal(winner),
+			expectedCallCount: 3, // edit + get + archive
+		},
+		{
+			name:              "missing field blocks archiving",
+			stored:            mustMarshal(missingPassword),
+			expectedCallCount: 2, // edit + get
+			expectErr:         true,
+		},
+		{
+			name:              "unreadable item blocks archiving",
+			stored:            []byte("not json"),
+			expectedCallCount: 2,
+			expectErr:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeOpClient{getOutput: tt.stored}
+			SetOpClient(client)
+			t.Cleanup(func() { SetOpClient(op.DefaultClient) })
+
+			err := ApplyMergeWithOptions(winner, losers, ApplyOptions{Verify: true})
+			if tt.expectErr && err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !tt.expectErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(client.calls) != tt.expectedCallCount {
+				t.Fatalf("expected %d op calls, got %d: %v", tt.expectedCallCount, len(client.calls), client.calls)
+			}
+			for _, call := range client.calls {
+				if call.args[1] == "delete" && tt.expectErr {
+					t.Fatalf("losers must not be archived when verification fails: %v", client.calls)
+				}
+			}
+		})
+	}
+}
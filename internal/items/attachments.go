@@ -0,0 +1,131 @@
+package items
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"1merge/internal/models"
+)
+
+// attachmentTransfer is a loser attachment that must be copied onto the winner before the loser is archived.
+type attachmentTransfer struct {
+	loser models.Item
+	file  models.File
+	// name is the attachment name on the winner, renamed if the winner already has a different file by that name.
+	name string
+}
+
+// planAttachments lists the loser attachments missing from the winner. A file with the same name
+// and size as one already on the winner (or planned earlier) is treated as the same document.
+func planAttachments(winner models.Item, losers []models.Item) []attachmentTransfer {
+	type fileKey struct {
+		name string
+		size int64
+	}
+	present := make(map[fileKey]bool)
+	names := make(map[string]bool)
+	for _, file := range winner.Files {
+		present[fileKey{file.Name, file.Size}] = true
+		names[file.Name] = true
+	}
+
+	var transfers []attachmentTransfer
+	for _, loser := range losers {
+		for _, file := range loser.Files {
+			key := fileKey{file.Name, file.Size}
+			if present[key] {
+				continue
+			}
+			present[key] = true
+
+			name := file.Name
+			if names[name] {
+				name = uniqueAttachmentName(file.Name, loser, names)
+			}
+			names[name] = true
+
+			transfers = append(transfers, attachmentTransfer{loser: loser, file: file, name: name})
+		}
+	}
+	return transfers
+}
+
+// uniqueAttachmentName derives a name such as "scan (from Old Login).pdf" that is not yet taken.
+func uniqueAttachmentName(name string, loser models.Item, taken map[string]bool) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	source := loser.Title
+	if source == "" {
+		source = loser.ID
+	}
+
+	candidate := fmt.Sprintf("%s (from %s)%s", base, source, ext)
+	for i := 2; taken[candidate]; i++ {
+		candidate = fmt.Sprintf("%s (from %s %d)%s", base, source, i, ext)
+	}
+	return candidate
+}
+
+// copyAttachments downloads each planned attachment into a private temp directory and attaches
+// it to the winner. The names of attachments added so far are passed to onAttached, so that a
+// failed apply can remove them again. The temp directory is always removed.
+func copyAttachments(winnerID string, transfers []attachmentTransfer, onAttached func(name string)) error {
+	dir, err := os.MkdirTemp("", "1merge-files-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir for attachments: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i, transfer := range transfers {
+		if transfer.loser.Vault.ID == "" {
+			return fmt.Errorf("failed to download attachment %q from item %s: unknown vault", transfer.file.Name, transfer.loser.ID)
+		}
+
+		// Index-prefixed paths keep downloads apart even if names repeat across losers
+		path := filepath.Join(dir, fmt.Sprintf("%d-%s", i, filepath.Base(transfer.file.ID)))
+		reference := fmt.Sprintf("op://%s/%s/%s", transfer.loser.Vault.ID, transfer.loser.ID, transfer.file.ID)
+		if _, err := opClient.RunOpCmd("read", "--out-file", path, reference); err != nil {
+			return fmt.Errorf("failed to download attachment %q from item %s: %w", transfer.file.Name, transfer.loser.ID, err)
+		}
+
+		assignment := fmt.Sprintf("%s[file]=%s", escapeAssignmentName(transfer.name), path)
+		if _, err := opClient.RunOpCmd("item", "edit", winnerID, assignment); err != nil {
+			return fmt.Errorf("failed to attach %q to item %s: %w", transfer.name, winnerID, err)
+		}
+		onAttached(transfer.name)
+	}
+
+	return nil
+}
+
+// removeAttachment deletes an attachment that copyAttachments added to an item.
+func removeAttachment(itemID string, name string) error {
+	if _, err := opClient.RunOpCmd("item", "edit", itemID, escapeAssignmentName(name)+"[delete]"); err != nil {
+		return fmt.Errorf("failed to remove attachment %q from item %s: %w", name, itemID, err)
+	}
+	return nil
+}
+
+// missingAttachments returns the planned attachment names that are not on the stored winner.
+func missingAttachments(stored models.Item, transfers []attachmentTransfer) []string {
+	onItem := make(map[string]bool)
+	for _, file := range stored.Files {
+		onItem[file.Name] = true
+	}
+
+	var missing []string
+	for _, transfer := range transfers {
+		if !onItem[transfer.name] {
+			missing = append(missing, fmt.Sprintf("attachment %q from item %s is missing", transfer.name, transfer.loser.ID))
+		}
+	}
+	return missing
+}
+
+// escapeAssignmentName escapes the characters that op assignment statements treat specially.
+func escapeAssignmentName(name string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `.`, `\.`, `=`, `\=`)
+	return replacer.Replace(name)
+}
@@ -0,0 +1,128 @@
+package items
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"1merge/internal/models"
+	"1merge/internal/op"
+)
+
+func TestPlanAttachments(t *testing.T) {
+	winner := models.Item{ID: "winner", Files: []models.File{{ID: "w1", Name: "scan.pdf", Size: 100}}}
+	loser1 := models.Item{ID: "loser1", Title: "Old Login", Files: []models.File{
+		{ID: "a", Name: "scan.pdf", Size: 100}, // same document as the winner's
+		{ID: "b", Name: "scan.pdf", Size: 250}, // different document, same name
+		{ID: "c", Name: "key.txt", Size: 10},
+	}}
+	loser2 := models.Item{ID: "loser2", Title: "Old Login", Files: []models.File{
+		{ID: "d", Name: "key.txt", Size: 10},   // already planned from loser1
+		{ID: "e", Name: "scan.pdf", Size: 300}, // collides with the winner and the renamed copy
+	}}
+
+	transfers := planAttachments(winner, []models.Item{loser1, loser2})
+
+	expected := []struct{ fileID, name string }{
+		{"b", "scan (from Old Login).pdf"},
+		{"c", "key.txt"},
+		{"e", "scan (from Old Login 2).pdf"},
+	}
+	if len(transfers) != len(expected) {
+		t.Fatalf("expected %d transfers, got %+v", len(expected), transfers)
+	}
+	for i, want := range expected {
+		if transfers[i].file.ID != want.fileID || transfers[i].name != want.name {
+			t.Errorf("transfer %d = %s as %q, expected %s as %q", i, transfers[i].file.ID, transfers[i].name, want.fileID, want.name)
+		}
+	}
+}
+
+func TestEscapeAssignmentName(t *testing.T) {
+	if got := escapeAssignmentName(`a.b=c\d`); got != `a\.b\=c\\d` {
+		t.Fatalf("escapeAssignmentName = %q", got)
+	}
+}
+
+// downloadFailingClient fails every "op read" and passes other commands through.
+type downloadFailingClient struct {
+	*failingOpClient
+}
+
+func (d downloadFailingClient) RunOpCmd(args ...string) ([]byte, error) {
+	if len(args) > 0 && args[0] == "read" {
+		d.calls = append(d.calls, strings.Join(args, " "))
+		return nil, errors.New("download failed")
+	}
+	return d.failingOpClient.RunOpCmd(args...)
+}
+
+func TestApplyMergeWithOptions_CopiesAttachments(t *testing.T) {
+	winner := createTestItem("winner", "Winner", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC))
+	loser := createTestItem("loser1", "Loser", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	loser.Files = []models.File{{ID: "file1", Name: "backup codes.txt", Size: 42}}
+
+	tests := []struct {
+		name          string
+		storedFiles   []models.File
+		failDownload  bool
+		expectArchive bool
+		expectRemoval bool
+	}{
+		{
+			name:          "attachment confirmed before archiving",
+			storedFiles:   []models.File{{ID: "new", Name: "backup codes.txt", Size: 42}},
+			expectArchive: true,
+		},
+		{
+			name:          "missing attachment blocks archiving and is rolled back",
+			storedFiles:   nil,
+			expectRemoval: true,
+		},
+		{
+			name:         "failed download blocks archiving",
+			storedFiles:  nil,
+			failDownload: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := winner
+			stored.Files = tt.storedFiles
+			storedJSON, err := json.Marshal(stored)
+			if err != nil {
+				t.Fatalf("failed to marshal item: %v", err)
+			}
+
+			client := &failingOpClient{getOutput: storedJSON}
+			if tt.failDownload {
+				SetOpClient(downloadFailingClient{client})
+			} else {
+				SetOpClient(client)
+			}
+			t.Cleanup(func() { SetOpClient(op.DefaultClient) })
+
+			err = ApplyMergeWithOptions(winner, []models.Item{loser}, ApplyOptions{Rollback: true})
+			if tt.expectArchive && err != nil {
+				t.Fatalf("ApplyMergeWithOptions returned error: %v", err)
+			}
+			if !tt.expectArchive && err == nil {
+				t.Fatal("expected an error when the attachment was not copied")
+			}
+
+			joined := strings.Join(client.calls, "\n")
+			if !strings.Contains(joined, "read --out-file") || !strings.Contains(joined, "op://test_vault/loser1/file1") {
+				t.Errorf("expected attachment download, got calls:\n%s", joined)
+			}
+			if strings.Contains(joined, "item delete loser1") != tt.expectArchive {
+				t.Errorf("archive expected=%v, got calls:\n%s", tt.expectArchive, joined)
+			}
+			if strings.Contains(joined, "backup codes\\.txt[delete]") != tt.expectRemoval {
+				t.Errorf("attachment removal expected=%v, got calls:\n%s", tt.expectRemoval, joined)
+			}
+		})
+	}
+}
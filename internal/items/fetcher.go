@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"1merge/internal/models"
+	"1merge/internal/workpool"
 )
 
 // FetchItems retrieves login items from 1Password
@@ -31,3 +32,48 @@ func FetchItems(vault string) ([]models.Item, error) {
 
 	return items, nil
 }
+
+// GetItem retrieves the full details of a single item, including all fields, from 1Password.
+func GetItem(id string) (models.Item, error) {
+	output, err := opClient.RunOpCmd("item", "get", id, "--format", "json")
+	if err != nil {
+		return models.Item{}, fmt.Errorf("failed to get item %s from 1Password: %w", id, err)
+	}
+
+	var item models.Item
+	if err := json.Unmarshal(output, &item); err != nil {
+		return models.Item{}, fmt.Errorf("failed to unmarshal 1Password item %s: %w", id, err)
+	}
+
+	return item, nil
+}
+
+// HydrateItems replaces item summaries from "op item list" with full details from "op item get",
+// fetching up to concurrency items at a time. The result keeps the input order. errs[i] is set
+// when item i could not be fetched, in which case the summary is returned in its place.
+func HydrateItems(summaries []models.Item, concurrency int) (hydrated []models.Item, errs []error) {
+	type result struct {
+		item models.Item
+		err  error
+	}
+
+	results := workpool.Map(summaries, concurrency, func(summary models.Item) result {
+		item, err := GetItem(summary.ID)
+		if err != nil {
+			return result{item: summary, err: err}
+		}
+		// "op item get" does not always repeat the list summary, which grouping relies on
+		if item.AdditionalInformation == "" {
+			item.AdditionalInformation = summary.AdditionalInformation
+		}
+		return result{item: item}
+	})
+
+	hydrated = make([]models.Item, len(results))
+	errs = make([]error, len(results))
+	for i, r := range results {
+		hydrated[i] = r.item
+		errs[i] = r.err
+	}
+	return hydrated, errs
+}
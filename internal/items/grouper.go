@@ -12,6 +12,20 @@ import (
 // slices of items that share the same domain and username. Only groups with 2 or more
 // items (actual duplicates) are returned in the map.
 func GroupDuplicates(items []models.Item) map[string][]models.Item {
+	return GroupDuplicatesWithEquivalents(items, nil)
+}
+
+// GroupDuplicatesWithEquivalents groups items like GroupDuplicates, treating the base domains in
+// each set of equivalents as one site. Such groups are keyed by the first domain of the set, so
+// with {"google.com", "youtube.com"} a YouTube login joins the Google logins of the same user.
+func GroupDuplicatesWithEquivalents(items []models.Item, equivalents [][]string) map[string][]models.Item {
+	sameSite := make(map[string]string)
+	for _, set := range equivalents {
+		for _, d := range set {
+			sameSite[strings.ToLower(strings.TrimSpace(d))] = strings.ToLower(strings.TrimSpace(set[0]))
+		}
+	}
+
 	groups := make(map[string][]models.Item)
 
 	for _, item := range items {
@@ -31,7 +45,11 @@ func GroupDuplicates(items []models.Item) map[string][]models.Item {
 		}
 
 		// Generate grouping key: baseDomain|username (case-insensitive)
-		key := strings.ToLower(baseDomain) + "|" + username
+		baseDomain = strings.ToLower(baseDomain)
+		if site, ok := sameSite[baseDomain]; ok {
+			baseDomain = site
+		}
+		key := baseDomain + "|" + username
 
 		// Append item to the group
 		groups[key] = append(groups[key], item)
@@ -47,6 +65,18 @@ func GroupDuplicates(items []models.Item) map[string][]models.Item {
 	return groups
 }
 
+// MissingGroupingData reports why GroupDuplicates would leave an item out: it has no URL with a
+// usable domain, no username, or both.
+func MissingGroupingData(item models.Item) (noURL, noUsername bool) {
+	url := getPrimaryURL(item)
+	if url == "" {
+		noURL = true
+	} else if _, err := domain.GetBaseDomain(url); err != nil {
+		noURL = true
+	}
+	return noURL, extractUsername(item) == ""
+}
+
 // extractUsername extracts the username from an item.
 // When using "op item list", the username is in AdditionalInformation.
 // When using "op item get", it's in the Fields array with Type="username".
@@ -331,6 +331,37 @@ func TestGroupDuplicates(t *testing.T) {
 	}
 }
 
+func TestGroupDuplicatesWithEquivalents(t *testing.T) {
+	login := func(id, href string) models.Item {
+		return models.Item{ID: id, URLs: []models.URL{{HRef: href, Primary: true}}, AdditionalInformation: "me@example.com"}
+	}
+	all := []models.Item{
+		login("1", "https://accounts.google.com"),
+		login("2", "https://www.youtube.com"),
+		login("3", "https://mail.google.com"),
+		login("4", "https://github.com"),
+		login("5", "https://www.amazon.de"),
+		login("6", "https://amazon.com"),
+	}
+	equivalents := [][]string{{"google.com", "YouTube.com"}, {"amazon.com", "amazon.de"}}
+
+	result := GroupDuplicatesWithEquivalents(all, equivalents)
+	expected := map[string]int{"google.com|me@example.com": 3, "amazon.com|me@example.com": 2}
+	if len(result) != len(expected) {
+		t.Fatalf("expected %d groups, got %d: %v", len(expected), len(result), result)
+	}
+	for key, count := range expected {
+		if len(result[key]) != count {
+			t.Fatalf("group %q has %d items, expected %d", key, len(result[key]), count)
+		}
+	}
+
+	// Without equivalents the sites stay apart
+	if groups := GroupDuplicates(all); len(groups) != 1 {
+		t.Fatalf("expected only the google.com group without equivalents, got %v", groups)
+	}
+}
+
 func TestExtractUsername(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -497,3 +528,25 @@ func TestGetPrimaryURL(t *testing.T) {
 		})
 	}
 }
+
+func TestMissingGroupingData(t *testing.T) {
+	tests := []struct {
+		name       string
+		item       models.Item
+		noURL      bool
+		noUsername bool
+	}{
+		{"complete", models.Item{URLs: []models.URL{{HRef: "https://google.com"}}, AdditionalInformation: "me"}, false, false},
+		{"no URL", models.Item{AdditionalInformation: "me"}, true, false},
+		{"unusable URL", models.Item{URLs: []models.URL{{HRef: "http://[::1"}}, AdditionalInformation: "me"}, true, false},
+		{"no username", models.Item{URLs: []models.URL{{HRef: "https://google.com"}}}, false, true},
+		{"neither", models.Item{}, true, true},
+	}
+
+	for _, tt := range tests {
+		noURL, noUsername := MissingGroupingData(tt.item)
+		if noURL != tt.noURL || noUsername != tt.noUsername {
+			t.Fatalf("%s: MissingGroupingData = %v, %v; expected %v, %v", tt.name, noURL, noUsername, tt.noURL, tt.noUsername)
+		}
+	}
+}
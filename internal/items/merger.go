@@ -1,6 +1,11 @@
 package items
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
+	"1merge/internal/domain"
 	"1merge/internal/models"
 )
 
@@ -22,17 +27,56 @@ func SelectWinner(items []models.Item) models.Item {
 	return winner
 }
 
+// MergedTag is the tag optionally added to items that absorbed duplicates, so they can be found later.
+const MergedTag = "1merge/merged"
+
 // CalculateMerge implements the Superset merge strategy, combining the winner and loser items.
 // It deep-copies the winner item and adds unique fields and URLs from the loser.
+// Tags are combined as a union, and the result is a favorite if either item was.
 // Conflicting fields (same label) are placed in an "Archived Conflicts" section.
-// Duplicate URLs are skipped.
+// URLs that only differ in form (see domain.CanonicalURL) are kept once, in their most specific form,
+// with their labels combined. Exactly one URL ends up primary (see primaryURL).
 func CalculateMerge(winner models.Item, loser models.Item) (models.Item, error) {
+	return CalculateMergeWithPolicy(winner, loser, nil)
+}
+
+// CalculateMergeWithPolicy is CalculateMerge with conflicting fields resolved by policy
+// instead of always being archived. A nil policy behaves exactly like CalculateMerge.
+func CalculateMergeWithPolicy(winner models.Item, loser models.Item, policy *MergePolicy) (models.Item, error) {
+	return calculateMerge(winner, loser, policy, newMergeSources())
+}
+
+// mergeSources tracks where merged values came from while several losers are folded into one
+// winner, so each loser is compared with the item that supplied a value rather than the winner.
+type mergeSources struct {
+	// updated maps a field label to the last update of the item whose value the merged field
+	// holds. Labels without an entry hold the winner's value.
+	updated map[string]time.Time
+	// otp is the item the active one-time password came from; nil means the winner.
+	otp *models.Item
+}
+
+func newMergeSources() *mergeSources {
+	return &mergeSources{updated: make(map[string]time.Time)}
+}
+
+// fieldUpdated returns the last update of the item that supplied the merged value of a field.
+func (s *mergeSources) fieldUpdated(label string, merged models.Item) time.Time {
+	if updated, ok := s.updated[label]; ok {
+		return updated
+	}
+	return merged.UpdatedAt
+}
+
+// calculateMerge is CalculateMergeWithPolicy recording value sources in sources across calls.
+func calculateMerge(winner models.Item, loser models.Item, policy *MergePolicy, sources *mergeSources) (models.Item, error) {
 	// Deep copy the winner
 	merged := models.Item{
 		ID:                    winner.ID,
 		Title:                 winner.Title,
 		Vault:                 winner.Vault,
 		Category:              winner.Category,
+		Favorite:              winner.Favorite || loser.Favorite,
 		UpdatedAt:             winner.UpdatedAt,
 		AdditionalInformation: winner.AdditionalInformation,
 	}
@@ -45,24 +89,58 @@ func CalculateMerge(winner models.Item, loser models.Item) (models.Item, error)
 			sectionCopy := *field.Section
 			merged.Fields[i].Section = &sectionCopy
 		}
+		if field.PasswordDetails != nil {
+			detailsCopy := *field.PasswordDetails
+			detailsCopy.History = append([]string(nil), field.PasswordDetails.History...)
+			merged.Fields[i].PasswordDetails = &detailsCopy
+		}
 	}
 
-	// Deep copy URLs from winner and track if winner has a primary URL
-	winnerHasPrimary := false
-	merged.URLs = make([]models.URL, len(winner.URLs))
-	for i, url := range winner.URLs {
-		merged.URLs[i] = url
-		if url.Primary {
-			winnerHasPrimary = true
+	// Copy attachment metadata from winner; loser attachments are copied by ApplyMergeWithOptions
+	for _, file := range winner.Files {
+		if file.Section != nil {
+			sectionCopy := *file.Section
+			file.Section = &sectionCopy
+		}
+		merged.Files = append(merged.Files, file)
+	}
+
+	// Tags are merged as a union, keeping the winner's tags first
+	merged.Tags = mergeTags(winner.Tags, loser.Tags)
+
+	// Deep copy URLs from winner
+	urlOpts := policy.urlOptions()
+	merged.URLs = make([]models.URL, 0, len(winner.URLs))
+	for _, url := range winner.URLs {
+		if i := canonicalURLIndex(merged.URLs, url.HRef, urlOpts); i >= 0 {
+			mergeURLForm(&merged.URLs[i], url)
+			merged.URLs[i].Primary = merged.URLs[i].Primary || url.Primary
+		} else {
+			merged.URLs = append(merged.URLs, url)
 		}
 	}
 
 	// Process loser's fields
 	for _, loserField := range loser.Fields {
+		action := policy.ActionFor(loserField)
+		if action == ActionDrop {
+			continue
+		}
+
+		// One-time passwords are matched by type, not label, so only one seed stays active
+		if isOTPField(loserField) && !isArchivedConflict(loserField) {
+			mergeOTP(&merged, loser, loserField, policy, sources)
+			continue
+		}
+
 		exists, existingField := fieldExistsByLabel(merged.Fields, loserField.Label)
 		if !exists {
 			// Unique field, add it
+			if action == ActionNotes {
+				loserField.Value = mergeNotes("", loser, loserField.Value)
+			}
 			merged.Fields = append(merged.Fields, loserField)
+			sources.updated[loserField.Label] = loser.UpdatedAt
 		} else {
 			sameValue := existingField.Value == loserField.Value
 			sameType := existingField.Type == loserField.Type
@@ -73,7 +151,41 @@ func CalculateMerge(winner models.Item, loser models.Item) (models.Item, error)
 				// Identical field, skip to avoid duplicate/conflict
 				continue
 			}
-			// Conflicting field, add to "Archived Conflicts" section
+
+			existing := &merged.Fields[fieldIndexByLabel(merged.Fields, loserField.Label)]
+			switch action {
+			case ActionKeepWinner:
+				continue
+			case ActionKeepNewest:
+				if loser.UpdatedAt.After(sources.fieldUpdated(loserField.Label, merged)) {
+					existing.Value = loserField.Value
+					sources.updated[loserField.Label] = loser.UpdatedAt
+				}
+				continue
+			case ActionKeepLongest:
+				if len(loserField.Value) > len(existing.Value) {
+					existing.Value = loserField.Value
+				}
+				continue
+			case ActionNotes:
+				existing.Value = mergeNotes(existing.Value, loser, loserField.Value)
+				continue
+			case ActionHistory:
+				addPreviousPassword(&merged, loser, loserField)
+				continue
+			case ActionConcatenate:
+				if existing.Value == "" {
+					existing.Value = loserField.Value
+				} else if !strings.Contains(existing.Value, loserField.Value) {
+					existing.Value = strings.TrimRight(existing.Value, "\n") + "\n" + loserField.Value
+				}
+				continue
+			}
+
+			// Conflicting field, add to "Archived Conflicts" section unless an earlier loser had the same value
+			if conflictArchived(merged.Fields, loserField) {
+				continue
+			}
 			section := getOrCreateArchivedConflictsSection(merged.Fields)
 			loserFieldCopy := loserField
 			loserFieldCopy.Section = section
@@ -81,20 +193,18 @@ func CalculateMerge(winner models.Item, loser models.Item) (models.Item, error)
 		}
 	}
 
-	// Process loser's URLs
+	// Process loser's URLs; which URL is primary is decided below
+	primary := primaryURL(merged.URLs, loser.URLs, policy)
 	for _, loserURL := range loser.URLs {
-		if !urlExists(merged.URLs, loserURL.HRef) {
+		if i := canonicalURLIndex(merged.URLs, loserURL.HRef, urlOpts); i >= 0 {
+			// Same address written differently, keep the most specific form
+			mergeURLForm(&merged.URLs[i], loserURL)
+		} else {
 			// Unique URL, add it
-			// If loser's URL is primary but winner already has a primary, demote loser's
-			if loserURL.Primary && winnerHasPrimary {
-				demotedURL := loserURL
-				demotedURL.Primary = false
-				merged.URLs = append(merged.URLs, demotedURL)
-			} else {
-				merged.URLs = append(merged.URLs, loserURL)
-			}
+			merged.URLs = append(merged.URLs, loserURL)
 		}
 	}
+	setPrimaryURL(merged.URLs, primary, urlOpts)
 
 	return merged, nil
 }
@@ -110,6 +220,64 @@ func fieldExistsByLabel(fields []models.Field, label string) (bool, models.Field
 	return false, models.Field{}
 }
 
+// addPreviousPassword records a loser's old value as a dated entry in the "Previous Passwords" section,
+// labelled with the loser's title and last update. Values already recorded there are not repeated.
+func addPreviousPassword(merged *models.Item, loser models.Item, loserField models.Field) {
+	for _, field := range merged.Fields {
+		if field.Section != nil && field.Section.ID == previousPasswordsSectionID && field.Value == loserField.Value {
+			return
+		}
+	}
+
+	source := loser.Title
+	if source == "" {
+		source = loser.ID
+	}
+
+	merged.Fields = append(merged.Fields, models.Field{
+		Type:    loserField.Type,
+		Label:   fmt.Sprintf("%s (%s)", source, loser.UpdatedAt.Format("2006-01-02")),
+		Value:   loserField.Value,
+		Section: getOrCreatePreviousPasswordsSection(merged.Fields),
+	})
+}
+
+// mergeTags returns the union of two tag lists in order of first appearance.
+// Tags differing only in case are treated as the same tag; the first spelling wins.
+func mergeTags(winnerTags []string, loserTags []string) []string {
+	if len(winnerTags) == 0 && len(loserTags) == 0 {
+		return nil
+	}
+
+	tags := make([]string, 0, len(winnerTags)+len(loserTags))
+	seen := make(map[string]bool)
+	for _, tag := range append(append([]string{}, winnerTags...), loserTags...) {
+		key := strings.ToLower(strings.TrimSpace(tag))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// AddTag returns a copy of item with tag added, unless the item already has it.
+func AddTag(item models.Item, tag string) models.Item {
+	item.Tags = mergeTags(item.Tags, []string{tag})
+	return item
+}
+
+// fieldIndexByLabel returns the index of the first field with the given label (case-sensitive), or -1.
+func fieldIndexByLabel(fields []models.Field, label string) int {
+	for i, field := range fields {
+		if field.Label == label {
+			return i
+		}
+	}
+	return -1
+}
+
 // urlExists checks if a URL with the given href already exists in the URLs slice (exact string match).
 // Returns true if found, false otherwise.
 func urlExists(urls []models.URL, href string) bool {
@@ -121,6 +289,134 @@ func urlExists(urls []models.URL, href string) bool {
 	return false
 }
 
+// canonicalURLIndex returns the index of the first URL with the same canonical form as href, or -1.
+// URLs that cannot be canonicalized only match exactly.
+func canonicalURLIndex(urls []models.URL, href string, opts domain.CanonicalOptions) int {
+	canonical, err := domain.CanonicalURL(href, opts)
+	for i, url := range urls {
+		if url.HRef == href {
+			return i
+		}
+		if err != nil {
+			continue
+		}
+		if existing, existingErr := domain.CanonicalURL(url.HRef, opts); existingErr == nil && existing == canonical {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeURLForm folds an equivalent URL into existing: the more specific href is kept,
+// and both labels are kept, joined with " / " when they differ. Primary is left unchanged.
+func mergeURLForm(existing *models.URL, other models.URL) {
+	if domain.MoreSpecificURL(other.HRef, existing.HRef) {
+		existing.HRef = other.HRef
+	}
+	existing.Label = mergeURLLabels(existing.Label, other.Label)
+}
+
+// urlLabelSeparator joins the distinct labels of URLs folded into one.
+const urlLabelSeparator = " / "
+
+// mergeURLLabels combines two URL labels, leaving out labels already present (ignoring case).
+func mergeURLLabels(existing string, other string) string {
+	other = strings.TrimSpace(other)
+	if other == "" {
+		return existing
+	}
+	if strings.TrimSpace(existing) == "" {
+		return other
+	}
+	for _, label := range strings.Split(existing, urlLabelSeparator) {
+		if strings.EqualFold(strings.TrimSpace(label), other) {
+			return existing
+		}
+	}
+	return existing + urlLabelSeparator + other
+}
+
+// primaryURL decides which URL of a merge is primary, in order of preference: the URL picked in
+// the policy, the primary URL already on the merged item, the loser's primary URL, and finally
+// the first URL. It returns "" if there are no URLs at all.
+func primaryURL(mergedURLs []models.URL, loserURLs []models.URL, policy *MergePolicy) string {
+	if policy != nil && policy.PrimaryURL != "" {
+		return policy.PrimaryURL
+	}
+	for _, urls := range [][]models.URL{mergedURLs, loserURLs} {
+		for _, url := range urls {
+			if url.Primary {
+				return url.HRef
+			}
+		}
+	}
+	for _, urls := range [][]models.URL{mergedURLs, loserURLs} {
+		if len(urls) > 0 {
+			return urls[0].HRef
+		}
+	}
+	return ""
+}
+
+// setPrimaryURL marks exactly one URL as primary: the one matching href, or the first URL
+// if none matches.
+func setPrimaryURL(urls []models.URL, href string, opts domain.CanonicalOptions) {
+	if len(urls) == 0 {
+		return
+	}
+	primary := canonicalURLIndex(urls, href, opts)
+	if primary < 0 {
+		primary = 0
+	}
+	for i := range urls {
+		urls[i].Primary = i == primary
+	}
+}
+
+// PrimaryURLOptions returns the distinct primary URLs of a group's items, the winner's first.
+// More than one option means the items disagree about which website is primary.
+func PrimaryURLOptions(winner models.Item, groupItems []models.Item, policy *MergePolicy) []string {
+	opts := policy.urlOptions()
+	members := append([]models.Item{winner}, groupItems...)
+
+	var options []models.URL
+	for _, item := range members {
+		for _, url := range item.URLs {
+			if url.Primary && canonicalURLIndex(options, url.HRef, opts) < 0 {
+				options = append(options, url)
+			}
+		}
+	}
+
+	hrefs := make([]string, len(options))
+	for i, url := range options {
+		hrefs[i] = url.HRef
+	}
+	return hrefs
+}
+
+// ConflictCount returns the number of fields in an item's "Archived Conflicts" section.
+func ConflictCount(item models.Item) int {
+	count := 0
+	for _, field := range item.Fields {
+		if isArchivedConflict(field) {
+			count++
+		}
+	}
+	return count
+}
+
+// conflictArchived reports whether the "Archived Conflicts" section already holds a field with the
+// same label, type and value as field.
+func conflictArchived(fields []models.Field, field models.Field) bool {
+	for _, existing := range fields {
+		if isArchivedConflict(existing) && existing.Label == field.Label && existing.Type == field.Type && existing.Value == field.Value {
+			return true
+		}
+	}
+	return false
+}
+
 // getOrCreateArchivedConflictsSection searches for or creates an "Archived Conflicts" section.
 // All conflicting fields are grouped under this section.
 // Note: This creates a section reference without explicitly defining it in a sections array.
@@ -137,3 +433,18 @@ func getOrCreateArchivedConflictsSection(fields []models.Field) *models.Section
 	section := &models.Section{ID: "archived_conflicts"}
 	return section
 }
+
+// previousPasswordsSectionID identifies the section that holds passwords replaced by a merge.
+const previousPasswordsSectionID = "previous_passwords"
+
+// getOrCreatePreviousPasswordsSection searches for or creates the "Previous Passwords" section,
+// so entries added by earlier merges and new ones share a single section.
+func getOrCreatePreviousPasswordsSection(fields []models.Field) *models.Section {
+	for i := range fields {
+		if fields[i].Section != nil && fields[i].Section.ID == previousPasswordsSectionID {
+			return fields[i].Section
+		}
+	}
+
+	return &models.Section{ID: previousPasswordsSectionID, Label: "Previous Passwords"}
+}
@@ -1,6 +1,7 @@
 package items
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -779,3 +780,282 @@ func TestGetOrCreateArchivedConflictsSection(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateMergeWithPolicy_PasswordHistory(t *testing.T) {
+	policy := (*MergePolicy)(nil).WithLabelRule("password", ActionHistory)
+
+	winner := models.Item{
+		ID:        "w",
+		Title:     "Example",
+		UpdatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Fields: []models.Field{
+			{ID: "password", Type: "CONCEALED", Label: "password", Value: "current",
+				PasswordDetails: &models.PasswordDetails{History: []string{"older"}}},
+			{Type: "CONCEALED", Label: "Old Example (2023-01-01)", Value: "ancient",
+				Section: &models.Section{ID: "previous_passwords", Label: "Previous Passwords"}},
+		},
+	}
+	loser1 := models.Item{
+		ID:        "l1",
+		Title:     "Example Login",
+		UpdatedAt: time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC),
+		Fields:    []models.Field{{ID: "password", Type: "CONCEALED", Label: "password", Value: "previous"}},
+	}
+	loser2 := models.Item{
+		ID:        "l2",
+		Title:     "Example Copy",
+		UpdatedAt: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+		Fields:    []models.Field{{ID: "password", Type: "CONCEALED", Label: "password", Value: "ancient"}},
+	}
+
+	merged, err := CalculateMergeWithPolicy(winner, loser1, policy)
+	if err != nil {
+		t.Fatalf("CalculateMergeWithPolicy returned error: %v", err)
+	}
+	merged, err = CalculateMergeWithPolicy(merged, loser2, policy)
+	if err != nil {
+		t.Fatalf("CalculateMergeWithPolicy returned error: %v", err)
+	}
+
+	if len(merged.Fields) != 3 {
+		t.Fatalf("expected active password plus two history entries, got %+v", merged.Fields)
+	}
+
+	active := merged.Fields[0]
+	if active.Value != "current" || active.Section != nil {
+		t.Fatalf("expected winner password to stay the only active one, got %+v", active)
+	}
+	if active.PasswordDetails == nil || len(active.PasswordDetails.History) != 1 {
+		t.Fatalf("expected existing password history to be kept, got %+v", active.PasswordDetails)
+	}
+	if active.PasswordDetails == winner.Fields[0].PasswordDetails {
+		t.Fatal("expected password details to be deep-copied")
+	}
+
+	entry := merged.Fields[2]
+	if entry.Label != "Example Login (2024-01-15)" || entry.Value != "previous" || entry.Type != "CONCEALED" {
+		t.Fatalf("unexpected history entry %+v", entry)
+	}
+	if entry.Section == nil || entry.Section.ID != "previous_passwords" {
+		t.Fatalf("expected entry in previous_passwords section, got %+v", entry.Section)
+	}
+
+	for _, field := range merged.Fields {
+		if field.Section != nil && field.Section.ID == "archived_conflicts" {
+			t.Fatalf("password should not be archived as a conflict: %+v", field)
+		}
+	}
+}
+
+func TestCalculateMerge_TagsAndFavorite(t *testing.T) {
+	tests := []struct {
+		name             string
+		winner           models.Item
+		loser            models.Item
+		expectedTags     []string
+		expectedFavorite bool
+	}{
+		{
+			name:             "tags are merged as a union",
+			winner:           models.Item{ID: "w", Tags: []string{"work", "finance"}},
+			loser:            models.Item{ID: "l", Tags: []string{"Finance", "shared", "work"}},
+			expectedTags:     []string{"work", "finance", "shared"},
+			expectedFavorite: false,
+		},
+		{
+			name:             "loser tags are kept when winner has none",
+			winner:           models.Item{ID: "w"},
+			loser:            models.Item{ID: "l", Tags: []string{"imported"}, Favorite: true},
+			expectedTags:     []string{"imported"},
+			expectedFavorite: true,
+		},
+		{
+			name:             "winner favorite is kept",
+			winner:           models.Item{ID: "w", Favorite: true},
+			loser:            models.Item{ID: "l"},
+			expectedTags:     nil,
+			expectedFavorite: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := CalculateMerge(tt.winner, tt.loser)
+			if err != nil {
+				t.Fatalf("CalculateMerge returned error: %v", err)
+			}
+			if len(merged.Tags) != len(tt.expectedTags) {
+				t.Fatalf("expected tags %v, got %v", tt.expectedTags, merged.Tags)
+			}
+			for i, tag := range tt.expectedTags {
+				if merged.Tags[i] != tag {
+					t.Fatalf("expected tags %v, got %v", tt.expectedTags, merged.Tags)
+				}
+			}
+			if merged.Favorite != tt.expectedFavorite {
+				t.Fatalf("expected favorite %v, got %v", tt.expectedFavorite, merged.Favorite)
+			}
+		})
+	}
+}
+
+func TestAddTag(t *testing.T) {
+	item := models.Item{ID: "w", Tags: []string{"work"}}
+
+	tagged := AddTag(item, MergedTag)
+	if len(tagged.Tags) != 2 || tagged.Tags[1] != MergedTag {
+		t.Fatalf("expected %q to be added, got %v", MergedTag, tagged.Tags)
+	}
+	if len(item.Tags) != 1 {
+		t.Fatalf("AddTag must not modify the original item, got %v", item.Tags)
+	}
+
+	again := AddTag(tagged, MergedTag)
+	if len(again.Tags) != 2 {
+		t.Fatalf("expected tag not to be added twice, got %v", again.Tags)
+	}
+}
+
+func TestCalculateMerge_URLNormalization(t *testing.T) {
+	winner := models.Item{ID: "winner", URLs: []models.URL{
+		{HRef: "example.com", Primary: true},
+		{HRef: "https://example.com/"},
+	}}
+	loser := models.Item{ID: "loser", URLs: []models.URL{
+		{HRef: "https://example.com", Label: "website", Primary: true},
+		{HRef: "https://example.com/login?utm_source=mail"},
+		{HRef: "https://example.com/login/"},
+		{HRef: "https://www.example.com/login?ref=x"},
+	}}
+
+	tests := []struct {
+		name     string
+		policy   *MergePolicy
+		expected []models.URL
+	}{
+		{
+			name: "default keeps www hosts apart",
+			expected: []models.URL{
+				{HRef: "https://example.com/", Label: "website", Primary: true},
+				{HRef: "https://example.com/login/"},
+				{HRef: "https://www.example.com/login?ref=x"},
+			},
+		},
+		{
+			name:   "fold www",
+			policy: (*MergePolicy)(nil).WithFoldWWW(),
+			expected: []models.URL{
+				{HRef: "https://example.com/", Label: "website", Primary: true},
+				{HRef: "https://example.com/login/"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := CalculateMergeWithPolicy(winner, loser, tt.policy)
+			if err != nil {
+				t.Fatalf("CalculateMergeWithPolicy returned error: %v", err)
+			}
+			if len(merged.URLs) != len(tt.expected) {
+				t.Fatalf("expected %d URLs, got %+v", len(tt.expected), merged.URLs)
+			}
+			for i, want := range tt.expected {
+				if merged.URLs[i] != want {
+					t.Errorf("URL %d = %+v, expected %+v", i, merged.URLs[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateMerge_PrimaryURLAndLabels(t *testing.T) {
+	tests := []struct {
+		name     string
+		winner   []models.URL
+		loser    []models.URL
+		policy   *MergePolicy
+		expected []models.URL
+	}{
+		{
+			name:   "winner's primary wins over loser's",
+			winner: []models.URL{{HRef: "https://a.example.com", Primary: true}},
+			loser:  []models.URL{{HRef: "https://b.example.com", Primary: true}},
+			expected: []models.URL{
+				{HRef: "https://a.example.com", Primary: true},
+				{HRef: "https://b.example.com"},
+			},
+		},
+		{
+			name:   "existing URL promoted when loser marks it primary",
+			winner: []models.URL{{HRef: "https://a.example.com"}, {HRef: "https://b.example.com"}},
+			loser:  []models.URL{{HRef: "https://b.example.com/", Primary: true}},
+			expected: []models.URL{
+				{HRef: "https://a.example.com"},
+				{HRef: "https://b.example.com/", Primary: true},
+			},
+		},
+		{
+			name:   "first URL becomes primary when none is marked",
+			winner: []models.URL{{HRef: "https://a.example.com"}},
+			loser:  []models.URL{{HRef: "https://b.example.com"}},
+			expected: []models.URL{
+				{HRef: "https://a.example.com", Primary: true},
+				{HRef: "https://b.example.com"},
+			},
+		},
+		{
+			name:   "picked URL overrides both primaries",
+			winner: []models.URL{{HRef: "https://a.example.com", Primary: true}},
+			loser:  []models.URL{{HRef: "https://b.example.com", Primary: true}},
+			policy: (*MergePolicy)(nil).WithPrimaryURL("https://b.example.com"),
+			expected: []models.URL{
+				{HRef: "https://a.example.com"},
+				{HRef: "https://b.example.com", Primary: true},
+			},
+		},
+		{
+			name:   "differing labels are both kept",
+			winner: []models.URL{{HRef: "https://a.example.com", Label: "website", Primary: true}},
+			loser: []models.URL{
+				{HRef: "https://a.example.com", Label: "login page"},
+				{HRef: "https://a.example.com/", Label: "Website"},
+			},
+			expected: []models.URL{
+				{HRef: "https://a.example.com/", Label: "website / login page", Primary: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := CalculateMergeWithPolicy(models.Item{ID: "w", URLs: tt.winner}, models.Item{ID: "l", URLs: tt.loser}, tt.policy)
+			if err != nil {
+				t.Fatalf("CalculateMergeWithPolicy returned error: %v", err)
+			}
+			if len(merged.URLs) != len(tt.expected) {
+				t.Fatalf("expected %d URLs, got %+v", len(tt.expected), merged.URLs)
+			}
+			for i, want := range tt.expected {
+				if merged.URLs[i] != want {
+					t.Errorf("URL %d = %+v, expected %+v", i, merged.URLs[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPrimaryURLOptions(t *testing.T) {
+	winner := models.Item{ID: "w", URLs: []models.URL{{HRef: "https://a.example.com", Primary: true}}}
+	groupItems := []models.Item{
+		{ID: "x", URLs: []models.URL{{HRef: "https://b.example.com", Primary: true}}},
+		winner,
+		{ID: "y", URLs: []models.URL{{HRef: "https://a.example.com/", Primary: true}, {HRef: "https://c.example.com"}}},
+	}
+
+	options := PrimaryURLOptions(winner, groupItems, nil)
+	expected := []string{"https://a.example.com", "https://b.example.com"}
+	if strings.Join(options, ",") != strings.Join(expected, ",") {
+		t.Fatalf("PrimaryURLOptions = %v, expected %v", options, expected)
+	}
+}
@@ -0,0 +1,71 @@
+package items
+
+import (
+	"sort"
+
+	"1merge/internal/models"
+)
+
+// MergeResult is the outcome of merging a whole duplicate group with MergeAll.
+type MergeResult struct {
+	// Item is the merged winner.
+	Item models.Item
+	// Sources maps the index of a field in Item.Fields to the IDs of the group members that
+	// held that value, in group order. Fields whose value was built from several items
+	// (such as merged notes) or that are empty have no entry.
+	Sources map[int][]string
+}
+
+// MergeAll merges every loser into the winner at once. Unlike folding losers with
+// CalculateMergeWithPolicy in whatever order they arrive, the result does not depend on
+// the order of losers: they are merged newest first (ties broken by ID), a conflicting
+// value shared by several losers is archived once, and the items each value came from
+// are recorded in MergeResult.Sources.
+func MergeAll(winner models.Item, losers []models.Item, policy *MergePolicy) (MergeResult, error) {
+	ordered := append([]models.Item(nil), losers...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if !ordered[i].UpdatedAt.Equal(ordered[j].UpdatedAt) {
+			return ordered[i].UpdatedAt.After(ordered[j].UpdatedAt)
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	merged := winner
+	sources := newMergeSources()
+	for _, loser := range ordered {
+		var err error
+		merged, err = calculateMerge(merged, loser, policy, sources)
+		if err != nil {
+			return MergeResult{}, err
+		}
+	}
+
+	members := append([]models.Item{winner}, ordered...)
+	return MergeResult{Item: merged, Sources: fieldSources(merged.Fields, members)}, nil
+}
+
+// fieldSources finds, for every non-empty field, the members holding the same value under the same
+// label. Fields moved into the "Archived Conflicts" or "Previous Passwords" sections may have been
+// relabelled, so for them a member field of the same type and value is enough.
+func fieldSources(fields []models.Field, members []models.Item) map[int][]string {
+	sources := make(map[int][]string)
+	for i, field := range fields {
+		if field.Value == "" {
+			continue
+		}
+		relabelled := isArchivedConflict(field) || (field.Section != nil && field.Section.ID == previousPasswordsSectionID)
+
+		for _, member := range members {
+			for _, memberField := range member.Fields {
+				if memberField.Value != field.Value {
+					continue
+				}
+				if memberField.Label == field.Label || (relabelled && memberField.Type == field.Type) {
+					sources[i] = append(sources[i], member.ID)
+					break
+				}
+			}
+		}
+	}
+	return sources
+}
@@ -0,0 +1,158 @@
+package items
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"1merge/internal/models"
+)
+
+func TestMergeAll(t *testing.T) {
+	winner := models.Item{
+		ID:        "winner",
+		Title:     "Winner",
+		UpdatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Fields: []models.Field{
+			{ID: "password", Type: "CONCEALED", Label: "password", Value: "new"},
+		},
+		URLs: []models.URL{{HRef: "https://example.com", Primary: true}},
+	}
+	losers := []models.Item{
+		{
+			ID:        "loser-a",
+			Title:     "Loser A",
+			UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Fields: []models.Field{
+				{ID: "password", Type: "CONCEALED", Label: "password", Value: "old"},
+				{Type: "STRING", Label: "pin", Value: "1234"},
+			},
+			Tags: []string{"a"},
+			URLs: []models.URL{{HRef: "https://a.example.com"}},
+		},
+		{
+			ID:        "loser-b",
+			Title:     "Loser B",
+			UpdatedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			Fields: []models.Field{
+				{ID: "password", Type: "CONCEALED", Label: "password", Value: "old"},
+				{Type: "STRING", Label: "pin", Value: "1234"},
+			},
+			Tags: []string{"b"},
+			URLs: []models.URL{{HRef: "https://b.example.com"}},
+		},
+	}
+
+	result, err := MergeAll(winner, losers, nil)
+	if err != nil {
+		t.Fatalf("MergeAll returned error: %v", err)
+	}
+
+	// The old password is shared by both losers, so it is archived once
+	archived := 0
+	for _, field := range result.Item.Fields {
+		if isArchivedConflict(field) {
+			archived++
+		}
+	}
+	if archived != 1 {
+		t.Fatalf("expected 1 archived conflict, got %d: %+v", archived, result.Item.Fields)
+	}
+
+	expectedSources := map[int][]string{
+		0: {"winner"},
+		1: {"loser-b", "loser-a"},
+		2: {"loser-b", "loser-a"},
+	}
+	if !reflect.DeepEqual(result.Sources, expectedSources) {
+		t.Errorf("Sources = %v, expected %v", result.Sources, expectedSources)
+	}
+
+	// Newest loser first, whatever the input order
+	reversed, err := MergeAll(winner, []models.Item{losers[1], losers[0]}, nil)
+	if err != nil {
+		t.Fatalf("MergeAll returned error: %v", err)
+	}
+	if !reflect.DeepEqual(result, reversed) {
+		t.Errorf("MergeAll depends on loser order:\n%+v\n%+v", result, reversed)
+	}
+	if !reflect.DeepEqual(result.Item.Tags, []string{"b", "a"}) {
+		t.Errorf("expected tags from newest loser first, got %v", result.Item.Tags)
+	}
+}
+
+func TestMergeAll_KeepNewestWithOlderWinner(t *testing.T) {
+	item := func(id, pin string, year int) models.Item {
+		return models.Item{
+			ID:        id,
+			UpdatedAt: time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC),
+			Fields:    []models.Field{{Type: "STRING", Label: "pin", Value: pin}},
+		}
+	}
+	// The winner was chosen for another reason, e.g. it holds the passkey, and is the oldest item
+	winner := item("winner", "2020", 2020)
+	losers := []models.Item{item("loser-2023", "2023", 2023), item("loser-2024", "2024", 2024)}
+	policy := &MergePolicy{Labels: map[string]FieldAction{"pin": ActionKeepNewest}}
+
+	result, err := MergeAll(winner, losers, policy)
+	if err != nil {
+		t.Fatalf("MergeAll returned error: %v", err)
+	}
+	if len(result.Item.Fields) != 1 || result.Item.Fields[0].Value != "2024" {
+		t.Fatalf("expected only the newest pin 2024, got %+v", result.Item.Fields)
+	}
+
+	// A field the winner lacks is compared with the loser that added it
+	winner.Fields = nil
+	result, err = MergeAll(winner, losers, policy)
+	if err != nil {
+		t.Fatalf("MergeAll returned error: %v", err)
+	}
+	if len(result.Item.Fields) != 1 || result.Item.Fields[0].Value != "2024" {
+		t.Fatalf("expected only the newest pin 2024, got %+v", result.Item.Fields)
+	}
+}
+
+func TestMergeAll_SharedOTPSecretArchivedOnce(t *testing.T) {
+	item := func(id string, month time.Month, secret string) models.Item {
+		return models.Item{
+			ID:        id,
+			Title:     id,
+			UpdatedAt: time.Date(2024, month, 1, 0, 0, 0, 0, time.UTC),
+			Fields:    []models.Field{{Type: "OTP", Label: "one-time password", Value: "otpauth://totp/X?secret=" + secret}},
+		}
+	}
+	winner := item("winner", 3, "AAAA")
+	losers := []models.Item{item("loser-a", 1, "BBBB"), item("loser-b", 2, "bbbb")}
+
+	tests := []struct {
+		name     string
+		policy   *MergePolicy
+		active   string
+		inactive string
+	}{
+		{"winner seed stays active", nil, "AAAA", "BBBB"},
+		// loser-b is folded first and archived, then loser-a's identical seed is chosen
+		{"chosen seed shared with an archived one", (*MergePolicy)(nil).WithOTP(OTPAsk, "loser-a"), "BBBB", "AAAA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := MergeAll(winner, losers, tt.policy)
+			if err != nil {
+				t.Fatalf("MergeAll returned error: %v", err)
+			}
+			var active, inactive []string
+			for _, field := range result.Item.Fields {
+				if isArchivedConflict(field) {
+					inactive = append(inactive, otpSecret(field.Value))
+				} else {
+					active = append(active, otpSecret(field.Value))
+				}
+			}
+			if len(active) != 1 || active[0] != tt.active || len(inactive) != 1 || inactive[0] != tt.inactive {
+				t.Fatalf("expected active %s and one inactive %s, got active %v and inactive %v", tt.active, tt.inactive, active, inactive)
+			}
+		})
+	}
+}
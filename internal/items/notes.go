@@ -0,0 +1,79 @@
+package items
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"1merge/internal/models"
+)
+
+// paragraphSeparator splits notes on blank lines.
+var paragraphSeparator = regexp.MustCompile(`\n[ \t]*\n`)
+
+// notesHeaderLine matches the provenance headers written by mergeNotes.
+var notesHeaderLine = regexp.MustCompile(`(?m)^--- Merged from .* ---\n?`)
+
+// isNotesField reports whether a field is an item's built-in notes field.
+func isNotesField(field models.Field) bool {
+	return field.ID == "notesPlain" || field.Label == "notesPlain" || field.Purpose == "NOTES"
+}
+
+// mergeNotes appends the paragraphs of a loser's note to the existing note under a provenance
+// header naming the loser. Paragraphs already present, exactly or nearly (ignoring case,
+// whitespace and punctuation), are left out; if nothing new remains, existing is returned as is.
+func mergeNotes(existing string, loser models.Item, loserNote string) string {
+	seen := make(map[string]bool)
+	for _, paragraph := range splitParagraphs(existing) {
+		seen[normalizeParagraph(paragraph)] = true
+	}
+
+	var fresh []string
+	for _, paragraph := range splitParagraphs(loserNote) {
+		key := normalizeParagraph(paragraph)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		fresh = append(fresh, paragraph)
+	}
+
+	if len(fresh) == 0 {
+		return existing
+	}
+
+	block := notesHeader(loser) + "\n" + strings.Join(fresh, "\n\n")
+	if strings.TrimSpace(existing) == "" {
+		return block
+	}
+	return strings.TrimRight(existing, "\n") + "\n\n" + block
+}
+
+// notesHeader describes where a block of merged notes came from.
+func notesHeader(loser models.Item) string {
+	return fmt.Sprintf("--- Merged from %q (ID: %s, updated %s) ---",
+		loser.Title, loser.ID, loser.UpdatedAt.Format("2006-01-02"))
+}
+
+// splitParagraphs splits a note into trimmed, non-empty paragraphs, ignoring provenance headers.
+func splitParagraphs(note string) []string {
+	note = strings.ReplaceAll(note, "\r\n", "\n")
+	note = notesHeaderLine.ReplaceAllString(note, "")
+	var paragraphs []string
+	for _, paragraph := range paragraphSeparator.Split(note, -1) {
+		if trimmed := strings.TrimSpace(paragraph); trimmed != "" {
+			paragraphs = append(paragraphs, trimmed)
+		}
+	}
+	return paragraphs
+}
+
+// normalizeParagraph reduces a paragraph to lowercase letters and digits separated by single
+// spaces, so paragraphs that differ only in case, spacing or punctuation compare equal.
+func normalizeParagraph(paragraph string) string {
+	words := strings.FieldsFunc(strings.ToLower(paragraph), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,100 @@
+package items
+
+import (
+	"testing"
+	"time"
+
+	"1merge/internal/models"
+)
+
+func TestMergeNotes(t *testing.T) {
+	loser := models.Item{ID: "abc123", Title: "Old Login", UpdatedAt: time.Date(2023, 5, 4, 0, 0, 0, 0, time.UTC)}
+	header := `--- Merged from "Old Login" (ID: abc123, updated 2023-05-04) ---`
+
+	tests := []struct {
+		name     string
+		existing string
+		loser    string
+		expected string
+	}{
+		{
+			name:     "new paragraphs are appended under a header",
+			existing: "Account PIN is with the bank.",
+			loser:    "Support number: 555-0100",
+			expected: "Account PIN is with the bank.\n\n" + header + "\nSupport number: 555-0100",
+		},
+		{
+			name:     "exact and near-exact paragraphs are removed",
+			existing: "Security answer is the street name.\n\nShared with Alex.",
+			loser:    "shared with alex\n\n  Security answer is the  street name  \n\nRenewal in March.",
+			expected: "Security answer is the street name.\n\nShared with Alex.\n\n" + header + "\nRenewal in March.",
+		},
+		{
+			name:     "nothing new leaves the note unchanged",
+			existing: "Shared with Alex.",
+			loser:    "SHARED WITH ALEX!",
+			expected: "Shared with Alex.",
+		},
+		{
+			name:     "empty winner note gets only the loser block",
+			existing: "",
+			loser:    "Line one\nline two",
+			expected: header + "\nLine one\nline two",
+		},
+		{
+			name:     "windows line endings split paragraphs",
+			existing: "First",
+			loser:    "First\r\n\r\nSecond",
+			expected: "First\n\n" + header + "\nSecond",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeNotes(tt.existing, loser, tt.loser); got != tt.expected {
+				t.Fatalf("mergeNotes() =\n%q\nexpected\n%q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCalculateMerge_NotesAreConcatenated(t *testing.T) {
+	winner := models.Item{
+		ID:     "w",
+		Fields: []models.Field{{ID: "notesPlain", Type: "STRING", Purpose: "NOTES", Label: "notesPlain", Value: "Winner note."}},
+	}
+	loser1 := models.Item{
+		ID: "l1", Title: "First", UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Fields: []models.Field{{ID: "notesPlain", Type: "STRING", Purpose: "NOTES", Label: "notesPlain", Value: "Winner note\n\nFrom first."}},
+	}
+	loser2 := models.Item{
+		ID: "l2", Title: "Second", UpdatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		Fields: []models.Field{{ID: "notesPlain", Type: "STRING", Purpose: "NOTES", Label: "notesPlain", Value: "from first.\n\nFrom second."}},
+	}
+
+	merged, err := CalculateMerge(winner, loser1)
+	if err != nil {
+		t.Fatalf("CalculateMerge returned error: %v", err)
+	}
+	merged, err = CalculateMerge(merged, loser2)
+	if err != nil {
+		t.Fatalf("CalculateMerge returned error: %v", err)
+	}
+
+	if len(merged.Fields) != 1 {
+		t.Fatalf("expected notes to stay a single field, got %+v", merged.Fields)
+	}
+	expected := "Winner note.\n\n" +
+		`--- Merged from "First" (ID: l1, updated 2024-01-01) ---` + "\nFrom first.\n\n" +
+		`--- Merged from "Second" (ID: l2, updated 2023-01-01) ---` + "\nFrom second."
+	if merged.Fields[0].Value != expected {
+		t.Fatalf("merged note =\n%q\nexpected\n%q", merged.Fields[0].Value, expected)
+	}
+
+	// A label rule still overrides the notes default
+	policy := &MergePolicy{Labels: map[string]FieldAction{"notesPlain": ActionKeepWinner}}
+	kept, _ := CalculateMergeWithPolicy(winner, loser1, policy)
+	if kept.Fields[0].Value != "Winner note." || len(kept.Fields) != 1 {
+		t.Fatalf("expected keep_winner rule to apply to notes, got %+v", kept.Fields)
+	}
+}
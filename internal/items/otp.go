@@ -0,0 +1,153 @@
+package items
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"1merge/internal/models"
+)
+
+// OTPMode selects which one-time password stays active when group members have different secrets.
+type OTPMode string
+
+const (
+	// OTPWinner keeps the winner's one-time password active (the default).
+	OTPWinner OTPMode = "winner"
+	// OTPNewest keeps the one-time password of the most recently updated item active.
+	OTPNewest OTPMode = "newest"
+	// OTPAsk lets the user pick the active one-time password; the CLI records the choice in MergePolicy.OTPFrom.
+	OTPAsk OTPMode = "ask"
+)
+
+// validOTPModes lists every mode accepted in a policy file or flag.
+var validOTPModes = map[OTPMode]bool{
+	OTPWinner: true,
+	OTPNewest: true,
+	OTPAsk:    true,
+}
+
+// ParseOTPMode validates a mode name; the empty string means OTPWinner.
+func ParseOTPMode(name string) (OTPMode, error) {
+	if name == "" {
+		return OTPWinner, nil
+	}
+	mode := OTPMode(strings.ToLower(name))
+	if !validOTPModes[mode] {
+		return "", fmt.Errorf("unknown OTP mode %q (expected winner, newest or ask)", name)
+	}
+	return mode, nil
+}
+
+// isOTPField reports whether a field holds a one-time password seed.
+func isOTPField(field models.Field) bool {
+	return strings.EqualFold(field.Type, "OTP")
+}
+
+// isArchivedConflict reports whether a field lives in the "Archived Conflicts" section.
+func isArchivedConflict(field models.Field) bool {
+	return field.Section != nil && field.Section.ID == "archived_conflicts"
+}
+
+// otpSecret extracts the comparable secret of a one-time password field. The value may be an
+// otpauth:// URI or a bare base32 secret; spaces, padding and case are ignored.
+func otpSecret(value string) string {
+	secret := value
+	if parsed, err := url.Parse(value); err == nil && strings.EqualFold(parsed.Scheme, "otpauth") {
+		secret = parsed.Query().Get("secret")
+	}
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	return strings.TrimRight(secret, "=")
+}
+
+// activeOTPIndex returns the index of the item's active one-time password field, or -1.
+func activeOTPIndex(fields []models.Field) int {
+	for i, field := range fields {
+		if isOTPField(field) && !isArchivedConflict(field) && field.Value != "" {
+			return i
+		}
+	}
+	return -1
+}
+
+// HasOTP reports whether an item has an active one-time password.
+func HasOTP(item models.Item) bool {
+	return activeOTPIndex(item.Fields) >= 0
+}
+
+// DistinctOTPSecrets counts the different active one-time password secrets in a group.
+func DistinctOTPSecrets(group []models.Item) int {
+	secrets := make(map[string]bool)
+	for _, item := range group {
+		if i := activeOTPIndex(item.Fields); i >= 0 {
+			secrets[otpSecret(item.Fields[i].Value)] = true
+		}
+	}
+	return len(secrets)
+}
+
+// mergeOTP folds a loser's one-time password field into merged so that exactly one stays active.
+// The seed that loses, whether the active one or the loser's, is moved to "Archived Conflicts" with
+// a label warning that it is inactive. sources records which item the active seed came from.
+func mergeOTP(merged *models.Item, loser models.Item, loserField models.Field, policy *MergePolicy, sources *mergeSources) {
+	activeIdx := activeOTPIndex(merged.Fields)
+	if activeIdx < 0 {
+		merged.Fields = append(merged.Fields, loserField)
+		sources.otp = &loser
+		return
+	}
+
+	active := merged.Fields[activeIdx]
+	if loserField.Value == "" || otpSecret(active.Value) == otpSecret(loserField.Value) {
+		return
+	}
+
+	activeFrom := *merged
+	if sources.otp != nil {
+		activeFrom = *sources.otp
+	}
+
+	loserWins := false
+	if policy != nil && policy.OTPFrom != "" {
+		loserWins = policy.OTPFrom == loser.ID
+	} else if policy.otpMode() == OTPNewest {
+		loserWins = loser.UpdatedAt.After(activeFrom.UpdatedAt)
+	}
+
+	inactive, source := loserField, loser.Title
+	if loserWins {
+		inactive, source = active, activeFrom.Title
+		merged.Fields[activeIdx].Value = loserField.Value
+		sources.otp = &loser
+		// The newly active seed may have been archived as inactive for an earlier loser
+		merged.Fields = removeArchivedOTP(merged.Fields, loserField.Value)
+	}
+
+	// Each inactive secret is archived once, like other conflicts (see conflictArchived)
+	if archivedOTPIndex(merged.Fields, inactive.Value) >= 0 {
+		return
+	}
+	inactive.ID = ""
+	inactive.Label = fmt.Sprintf("%s (INACTIVE one-time password from %q, not in use)", inactive.Label, source)
+	inactive.Section = getOrCreateArchivedConflictsSection(merged.Fields)
+	merged.Fields = append(merged.Fields, inactive)
+}
+
+// archivedOTPIndex returns the index of an inactive one-time password in "Archived Conflicts"
+// with the same secret as value, or -1.
+func archivedOTPIndex(fields []models.Field, value string) int {
+	for i, field := range fields {
+		if isOTPField(field) && isArchivedConflict(field) && otpSecret(field.Value) == otpSecret(value) {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeArchivedOTP drops the inactive one-time passwords with the same secret as value.
+func removeArchivedOTP(fields []models.Field, value string) []models.Field {
+	for i := archivedOTPIndex(fields, value); i >= 0; i = archivedOTPIndex(fields, value) {
+		fields = append(fields[:i], fields[i+1:]...)
+	}
+	return fields
+}
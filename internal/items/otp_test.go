@@ -0,0 +1,214 @@
+package items
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"1merge/internal/models"
+)
+
+func otpField(label, value string) models.Field {
+	return models.Field{ID: "otp", Type: "OTP", Label: label, Value: value}
+}
+
+func TestOtpSecret(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"otpauth://totp/Example:user?secret=JBSWY3DPEHPK3PXP&issuer=Example", "JBSWY3DPEHPK3PXP"},
+		{"otpauth://totp/Other?issuer=Other&secret=jbsw%20y3dp%20ehpk%203pxp", "JBSWY3DPEHPK3PXP"},
+		{"jbsw y3dp ehpk 3pxp", "JBSWY3DPEHPK3PXP"},
+		{"JBSWY3DPEHPK3PXP====", "JBSWY3DPEHPK3PXP"},
+	}
+
+	for _, tt := range tests {
+		if got := otpSecret(tt.value); got != tt.expected {
+			t.Errorf("otpSecret(%q) = %q, expected %q", tt.value, got, tt.expected)
+		}
+	}
+}
+
+func TestDistinctOTPSecrets(t *testing.T) {
+	group := []models.Item{
+		{ID: "a", Fields: []models.Field{otpField("one-time password", "otpauth://totp/A?secret=AAAA")}},
+		{ID: "b", Fields: []models.Field{otpField("2FA", "aaaa")}},
+		{ID: "c", Fields: []models.Field{otpField("one-time password", "otpauth://totp/C?secret=CCCC")}},
+		{ID: "d"},
+	}
+
+	if got := DistinctOTPSecrets(group); got != 2 {
+		t.Fatalf("DistinctOTPSecrets = %d, expected 2", got)
+	}
+	if !HasOTP(group[0]) || HasOTP(group[3]) {
+		t.Fatal("HasOTP reported the wrong items")
+	}
+}
+
+func TestCalculateMerge_OTP(t *testing.T) {
+	winner := models.Item{
+		ID:        "w",
+		Title:     "Winner",
+		UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Fields:    []models.Field{otpField("one-time password", "otpauth://totp/W?secret=WWWW")},
+	}
+	newerLoser := models.Item{
+		ID:        "l",
+		Title:     "Loser",
+		UpdatedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		Fields:    []models.Field{otpField("2FA code", "otpauth://totp/L?secret=LLLL")},
+	}
+
+	tests := []struct {
+		name           string
+		loser          models.Item
+		policy         *MergePolicy
+		expectedActive string
+		expectedFields int
+		inactiveFrom   string
+	}{
+		{
+			name:           "same secret under another label is not duplicated",
+			loser:          models.Item{ID: "l", Fields: []models.Field{otpField("2FA code", "wwww")}},
+			expectedActive: "otpauth://totp/W?secret=WWWW",
+			expectedFields: 1,
+		},
+		{
+			name:           "winner mode archives the loser seed",
+			loser:          newerLoser,
+			expectedActive: "otpauth://totp/W?secret=WWWW",
+			expectedFields: 2,
+			inactiveFrom:   `from "Loser"`,
+		},
+		{
+			name:           "newest mode activates the newer loser seed",
+			loser:          newerLoser,
+			policy:         (*MergePolicy)(nil).WithOTP(OTPNewest, ""),
+			expectedActive: "otpauth://totp/L?secret=LLLL",
+			expectedFields: 2,
+			inactiveFrom:   `from "Winner"`,
+		},
+		{
+			name:           "user choice overrides the mode",
+			loser:          newerLoser,
+			policy:         (*MergePolicy)(nil).WithOTP(OTPAsk, "l"),
+			expectedActive: "otpauth://totp/L?secret=LLLL",
+			expectedFields: 2,
+			inactiveFrom:   `from "Winner"`,
+		},
+		{
+			name:           "loser seed is added when winner has none",
+			loser:          newerLoser,
+			expectedActive: "otpauth://totp/L?secret=LLLL",
+			expectedFields: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := winner
+			if tt.name == "loser seed is added when winner has none" {
+				w.Fields = nil
+			}
+
+			merged, err := CalculateMergeWithPolicy(w, tt.loser, tt.policy)
+			if err != nil {
+				t.Fatalf("CalculateMergeWithPolicy returned error: %v", err)
+			}
+			if len(merged.Fields) != tt.expectedFields {
+				t.Fatalf("expected %d fields, got %+v", tt.expectedFields, merged.Fields)
+			}
+
+			active := 0
+			for _, field := range merged.Fields {
+				if isArchivedConflict(field) {
+					if !strings.Contains(field.Label, "INACTIVE") || !strings.Contains(field.Label, tt.inactiveFrom) {
+						t.Errorf("expected archived seed to carry an inactive warning %s, got label %q", tt.inactiveFrom, field.Label)
+					}
+					continue
+				}
+				active++
+				if field.Value != tt.expectedActive {
+					t.Errorf("active OTP = %q, expected %q", field.Value, tt.expectedActive)
+				}
+			}
+			if active != 1 {
+				t.Fatalf("expected exactly one active OTP, got %d", active)
+			}
+		})
+	}
+
+	if winner.Fields[0].Value != "otpauth://totp/W?secret=WWWW" {
+		t.Fatal("winner was mutated")
+	}
+}
+
+func TestMergeAll_OTPNewestWithOlderWinner(t *testing.T) {
+	item := func(id string, year int, secret string) models.Item {
+		return models.Item{
+			ID:        id,
+			Title:     id,
+			UpdatedAt: time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC),
+			Fields:    []models.Field{otpField("one-time password", "otpauth://totp/X?secret="+secret)},
+		}
+	}
+	winner := item("winner-2020", 2020, "AAAA")
+	losers := []models.Item{item("loser-2023", 2023, "BBBB"), item("loser-2024", 2024, "CCCC")}
+
+	result, err := MergeAll(winner, losers, (*MergePolicy)(nil).WithOTP(OTPNewest, ""))
+	if err != nil {
+		t.Fatalf("MergeAll returned error: %v", err)
+	}
+
+	inactive := make(map[string]string)
+	for _, field := range result.Item.Fields {
+		if !isArchivedConflict(field) {
+			if otpSecret(field.Value) != "CCCC" {
+				t.Errorf("expected the newest seed CCCC to be active, got %q", field.Value)
+			}
+			continue
+		}
+		inactive[otpSecret(field.Value)] = field.Label
+	}
+	if !strings.Contains(inactive["AAAA"], `from "winner-2020"`) || !strings.Contains(inactive["BBBB"], `from "loser-2023"`) || len(inactive) != 2 {
+		t.Fatalf("expected the winner and 2023 seeds archived as inactive, got %v", inactive)
+	}
+}
+
+func TestMergeAll_OTPNewestArchivesReplacedSeedOnce(t *testing.T) {
+	item := func(id string, year int, secret string) models.Item {
+		return models.Item{
+			ID:        id,
+			Title:     id,
+			UpdatedAt: time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC),
+			Fields:    []models.Field{otpField("one-time password", "otpauth://totp/X?secret="+secret)},
+		}
+	}
+	// The winner's seed is replaced by the 2024 one, then the 2023 loser brings it up again
+	winner := item("winner-2020", 2020, "AAAA")
+	losers := []models.Item{item("loser-2024", 2024, "BBBB"), item("loser-2023", 2023, "AAAA"), item("loser-2022", 2022, "BBBB")}
+
+	result, err := MergeAll(winner, losers, (*MergePolicy)(nil).WithOTP(OTPNewest, ""))
+	if err != nil {
+		t.Fatalf("MergeAll returned error: %v", err)
+	}
+	if len(result.Item.Fields) != 2 {
+		t.Fatalf("expected the active seed and one inactive seed, got %+v", result.Item.Fields)
+	}
+	if otpSecret(result.Item.Fields[0].Value) != "BBBB" || otpSecret(result.Item.Fields[1].Value) != "AAAA" || !isArchivedConflict(result.Item.Fields[1]) {
+		t.Fatalf("expected BBBB active and AAAA archived, got %+v", result.Item.Fields)
+	}
+}
+
+func TestParseOTPMode(t *testing.T) {
+	if mode, err := ParseOTPMode(""); err != nil || mode != OTPWinner {
+		t.Fatalf("ParseOTPMode(\"\") = %q, %v; expected winner", mode, err)
+	}
+	if mode, err := ParseOTPMode("Newest"); err != nil || mode != OTPNewest {
+		t.Fatalf("ParseOTPMode(\"Newest\") = %q, %v; expected newest", mode, err)
+	}
+	if _, err := ParseOTPMode("loser"); err == nil {
+		t.Fatal("expected error for unknown mode")
+	}
+}
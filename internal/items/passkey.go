@@ -0,0 +1,51 @@
+package items
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"1merge/internal/models"
+)
+
+// ErrMultiplePasskeys is returned when more than one member of a group holds a passkey.
+// A passkey cannot be moved by a template edit, so archiving either item would destroy one.
+var ErrMultiplePasskeys = errors.New("more than one item holds a passkey")
+
+// HasPasskey reports whether an item holds a passkey, i.e. a field of type PASSKEY. Labels are
+// not checked, since any text field may mention passkeys. Passkeys only show up in full item
+// details, so items must be hydrated with GetItem before this is meaningful.
+func HasPasskey(item models.Item) bool {
+	for _, field := range item.Fields {
+		if strings.EqualFold(field.Type, "PASSKEY") {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectWinnerForMerge selects the item that survives a merge. An item holding a passkey always
+// wins, since its passkey cannot be carried over to another item; otherwise the most recent item
+// wins as in SelectWinner. If several items hold passkeys, ErrMultiplePasskeys is returned.
+func SelectWinnerForMerge(group []models.Item) (models.Item, error) {
+	var holders []models.Item
+	for _, item := range group {
+		if HasPasskey(item) {
+			holders = append(holders, item)
+		}
+	}
+
+	switch len(holders) {
+	case 0:
+		return SelectWinner(group), nil
+	case 1:
+		return holders[0], nil
+	default:
+		titles := make([]string, len(holders))
+		for i, item := range holders {
+			titles[i] = fmt.Sprintf("%q", item.Title)
+		}
+		return models.Item{}, fmt.Errorf("%w (%s); merging would archive a passkey, which cannot be moved to another item",
+			ErrMultiplePasskeys, strings.Join(titles, ", "))
+	}
+}
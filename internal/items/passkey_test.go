@@ -0,0 +1,65 @@
+package items
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"1merge/internal/models"
+)
+
+func TestHasPasskey(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     models.Item
+		expected bool
+	}{
+		{"no fields", models.Item{}, false},
+		{"passkey field type", models.Item{Fields: []models.Field{{Type: "PASSKEY", Label: "passkey"}}}, true},
+		{"text field mentioning passkeys", models.Item{Fields: []models.Field{{Type: "STRING", Label: "Passkey backup notes"}}}, false},
+		{"regular login", models.Item{Fields: []models.Field{{Type: "CONCEALED", Label: "password"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasPasskey(tt.item); got != tt.expected {
+				t.Fatalf("HasPasskey() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSelectWinnerForMerge(t *testing.T) {
+	passkey := []models.Field{{Type: "PASSKEY", Label: "passkey"}}
+	older := models.Item{ID: "older", Title: "Older", UpdatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	newer := models.Item{ID: "newer", Title: "Newer", UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	t.Run("most recent wins without passkeys", func(t *testing.T) {
+		winner, err := SelectWinnerForMerge([]models.Item{older, newer})
+		if err != nil || winner.ID != "newer" {
+			t.Fatalf("expected newer to win, got %q, %v", winner.ID, err)
+		}
+	})
+
+	t.Run("passkey holder wins even if older", func(t *testing.T) {
+		holder := older
+		holder.Fields = passkey
+		winner, err := SelectWinnerForMerge([]models.Item{newer, holder})
+		if err != nil || winner.ID != "older" {
+			t.Fatalf("expected passkey holder to win, got %q, %v", winner.ID, err)
+		}
+	})
+
+	t.Run("two passkey holders block the merge", func(t *testing.T) {
+		a, b := older, newer
+		a.Fields, b.Fields = passkey, passkey
+		_, err := SelectWinnerForMerge([]models.Item{a, b})
+		if !errors.Is(err, ErrMultiplePasskeys) {
+			t.Fatalf("expected ErrMultiplePasskeys, got %v", err)
+		}
+		if !strings.Contains(err.Error(), `"Older"`) || !strings.Contains(err.Error(), `"Newer"`) {
+			t.Fatalf("expected error to name both items, got %q", err.Error())
+		}
+	})
+}
@@ -0,0 +1,234 @@
+package items
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"1merge/internal/domain"
+	"1merge/internal/models"
+)
+
+// FieldAction names how a field present on both the merged item and a loser is resolved.
+type FieldAction string
+
+const (
+	// ActionArchive moves the loser's differing copy to the "Archived Conflicts" section (the default).
+	ActionArchive FieldAction = "archive"
+	// ActionKeepWinner keeps the winner's value and discards the loser's.
+	ActionKeepWinner FieldAction = "keep_winner"
+	// ActionKeepNewest keeps the value from whichever item was updated most recently.
+	ActionKeepNewest FieldAction = "keep_newest"
+	// ActionKeepLongest keeps the longer of the two values.
+	ActionKeepLongest FieldAction = "keep_longest"
+	// ActionConcatenate appends the loser's value to the winner's on a new line.
+	ActionConcatenate FieldAction = "concatenate"
+	// ActionDrop never carries the loser's field over, even when the winner lacks it.
+	ActionDrop FieldAction = "drop"
+	// ActionHistory records the loser's differing value as a dated entry in the
+	// "Previous Passwords" section, leaving the winner's field as the only active one.
+	ActionHistory FieldAction = "history"
+	// ActionNotes appends the loser's note paragraphs to the winner's under a provenance header,
+	// leaving out paragraphs the winner already has. It is the default for the notes field.
+	ActionNotes FieldAction = "notes"
+)
+
+// validFieldActions lists every action accepted in a policy file.
+var validFieldActions = map[FieldAction]bool{
+	ActionArchive:     true,
+	ActionKeepWinner:  true,
+	ActionKeepNewest:  true,
+	ActionKeepLongest: true,
+	ActionConcatenate: true,
+	ActionDrop:        true,
+	ActionHistory:     true,
+	ActionNotes:       true,
+}
+
+// MergePolicy decides, per field, how CalculateMergeWithPolicy resolves fields that exist on both items.
+// Rules are matched case-insensitively; a label rule wins over a type rule, which wins over Default.
+// One-time password fields are always reconciled so that a single seed stays active (see OTP).
+type MergePolicy struct {
+	Default FieldAction            `json:"default,omitempty"`
+	Types   map[string]FieldAction `json:"types,omitempty"`
+	Labels  map[string]FieldAction `json:"labels,omitempty"`
+	// OTP selects which one-time password stays active when secrets differ.
+	OTP OTPMode `json:"otp,omitempty"`
+	// OTPFrom is the ID of the item whose one-time password stays active, overriding OTP.
+	// It is set per group once the user has answered an OTPAsk prompt.
+	OTPFrom string `json:"-"`
+	// Title selects how the merged item's title is chosen.
+	Title TitleStrategy `json:"title,omitempty"`
+	// TitleOverride is the title picked by the user for the group, overriding Title.
+	// It is set per group once the user has answered a TitleAsk prompt.
+	TitleOverride string `json:"-"`
+	// PrimaryURL is the URL picked by the user as the merged item's primary website.
+	// It is set per group in interactive mode when the items disagree.
+	PrimaryURL string `json:"-"`
+	// FoldWWW treats "www.example.com" and "example.com" as the same site when deduplicating URLs.
+	FoldWWW bool `json:"fold_www,omitempty"`
+}
+
+// LoadPolicy reads a JSON merge policy file, for example:
+//
+//	{
+//	  "default": "archive",
+//	  "types": {"CONCEALED": "keep_newest"},
+//	  "labels": {"security question": "keep_winner", "recovery codes": "concatenate"},
+//	  "title": "shortest",
+//	  "fold_www": true
+//	}
+func LoadPolicy(path string) (*MergePolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read merge policy: %w", err)
+	}
+
+	var policy MergePolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse merge policy %s: %w", path, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid merge policy %s: %w", path, err)
+	}
+
+	return &policy, nil
+}
+
+// Validate checks that every rule names a known action.
+func (p *MergePolicy) Validate() error {
+	if p.OTP != "" && !validOTPModes[p.OTP] {
+		return fmt.Errorf("unknown OTP mode %q", p.OTP)
+	}
+	if p.Title != "" && !validTitleStrategies[p.Title] {
+		return fmt.Errorf("unknown title strategy %q", p.Title)
+	}
+	if p.Default != "" && !validFieldActions[p.Default] {
+		return fmt.Errorf("unknown default action %q", p.Default)
+	}
+	for fieldType, action := range p.Types {
+		if !validFieldActions[action] {
+			return fmt.Errorf("unknown action %q for type %q", action, fieldType)
+		}
+	}
+	for label, action := range p.Labels {
+		if !validFieldActions[action] {
+			return fmt.Errorf("unknown action %q for label %q", action, label)
+		}
+	}
+	return nil
+}
+
+// ActionFor returns the action that applies to a field. Without a matching label rule,
+// the notes field is always merged with ActionNotes; a nil policy archives everything else.
+func (p *MergePolicy) ActionFor(field models.Field) FieldAction {
+	if p != nil {
+		if action, ok := lookupFold(p.Labels, field.Label); ok {
+			return action
+		}
+	}
+	if isNotesField(field) {
+		return ActionNotes
+	}
+	if p == nil {
+		return ActionArchive
+	}
+	if action, ok := lookupFold(p.Types, field.Type); ok {
+		return action
+	}
+	if p.Default != "" {
+		return p.Default
+	}
+	return ActionArchive
+}
+
+// WithLabelRule returns a copy of the policy with an extra label rule; p may be nil.
+func (p *MergePolicy) WithLabelRule(label string, action FieldAction) *MergePolicy {
+	policy := p.clone()
+	policy.Labels[label] = action
+	return policy
+}
+
+// WithOTP returns a copy of the policy using the given OTP mode and active item; p may be nil.
+func (p *MergePolicy) WithOTP(mode OTPMode, from string) *MergePolicy {
+	policy := p.clone()
+	policy.OTP = mode
+	policy.OTPFrom = from
+	return policy
+}
+
+// clone returns a copy of the policy whose label rules can be modified safely; p may be nil.
+func (p *MergePolicy) clone() *MergePolicy {
+	policy := &MergePolicy{Labels: map[string]FieldAction{}}
+	if p != nil {
+		policy.Default = p.Default
+		policy.Types = p.Types
+		policy.OTP = p.OTP
+		policy.OTPFrom = p.OTPFrom
+		policy.Title = p.Title
+		policy.TitleOverride = p.TitleOverride
+		policy.PrimaryURL = p.PrimaryURL
+		policy.FoldWWW = p.FoldWWW
+		for key, value := range p.Labels {
+			policy.Labels[key] = value
+		}
+	}
+	return policy
+}
+
+// WithTitle returns a copy of the policy using the given title strategy and chosen title; p may be nil.
+func (p *MergePolicy) WithTitle(strategy TitleStrategy, override string) *MergePolicy {
+	policy := p.clone()
+	policy.Title = strategy
+	policy.TitleOverride = override
+	return policy
+}
+
+// WithPrimaryURL returns a copy of the policy that makes href the primary URL; p may be nil.
+func (p *MergePolicy) WithPrimaryURL(href string) *MergePolicy {
+	policy := p.clone()
+	policy.PrimaryURL = href
+	return policy
+}
+
+// WithFoldWWW returns a copy of the policy that folds "www." when deduplicating URLs; p may be nil.
+func (p *MergePolicy) WithFoldWWW() *MergePolicy {
+	policy := p.clone()
+	policy.FoldWWW = true
+	return policy
+}
+
+// urlOptions returns the URL canonicalization options for the policy; p may be nil.
+func (p *MergePolicy) urlOptions() domain.CanonicalOptions {
+	return domain.CanonicalOptions{FoldWWW: p != nil && p.FoldWWW}
+}
+
+// titleStrategy returns the configured title strategy, defaulting to TitleWinner; p may be nil.
+func (p *MergePolicy) titleStrategy() TitleStrategy {
+	if p == nil || p.Title == "" {
+		return TitleWinner
+	}
+	return p.Title
+}
+
+// otpMode returns the configured OTP mode, defaulting to OTPWinner; p may be nil.
+func (p *MergePolicy) otpMode() OTPMode {
+	if p == nil || p.OTP == "" {
+		return OTPWinner
+	}
+	return p.OTP
+}
+
+// lookupFold finds a map entry whose key matches name case-insensitively.
+func lookupFold(rules map[string]FieldAction, name string) (FieldAction, bool) {
+	if action, ok := rules[name]; ok {
+		return action, true
+	}
+	for key, action := range rules {
+		if strings.EqualFold(strings.TrimSpace(key), strings.TrimSpace(name)) {
+			return action, true
+		}
+	}
+	return "", false
+}
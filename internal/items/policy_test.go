@@ -0,0 +1,129 @@
+package items
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"1merge/internal/models"
+)
+
+func TestMergePolicy_ActionFor(t *testing.T) {
+	policy := &MergePolicy{
+		Default: ActionKeepWinner,
+		Types:   map[string]FieldAction{"CONCEALED": ActionKeepNewest},
+		Labels:  map[string]FieldAction{"Recovery Codes": ActionConcatenate},
+	}
+
+	tests := []struct {
+		name     string
+		policy   *MergePolicy
+		field    models.Field
+		expected FieldAction
+	}{
+		{"nil policy archives", nil, models.Field{Label: "password", Type: "CONCEALED"}, ActionArchive},
+		{"empty policy archives", &MergePolicy{}, models.Field{Label: "pin"}, ActionArchive},
+		{"label rule is case-insensitive", policy, models.Field{Label: "recovery codes", Type: "CONCEALED"}, ActionConcatenate},
+		{"type rule is case-insensitive", policy, models.Field{Label: "pin", Type: "concealed"}, ActionKeepNewest},
+		{"default applies otherwise", policy, models.Field{Label: "website", Type: "STRING"}, ActionKeepWinner},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.ActionFor(tt.field); got != tt.expected {
+				t.Fatalf("ActionFor(%+v) = %q, expected %q", tt.field, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.json")
+	os.WriteFile(valid, []byte(`{"default":"archive","labels":{"security question":"keep_winner"}}`), 0o600)
+	policy, err := LoadPolicy(valid)
+	if err != nil {
+		t.Fatalf("LoadPolicy returned error: %v", err)
+	}
+	if policy.ActionFor(models.Field{Label: "Security Question"}) != ActionKeepWinner {
+		t.Fatalf("expected label rule to be loaded, got %+v", policy)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalid, []byte(`{"types":{"OTP":"merge_somehow"}}`), 0o600)
+	if _, err := LoadPolicy(invalid); err == nil || !strings.Contains(err.Error(), "merge_somehow") {
+		t.Fatalf("expected unknown action error, got %v", err)
+	}
+
+	if _, err := LoadPolicy(filepath.Join(dir, "missing.json")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestCalculateMergeWithPolicy(t *testing.T) {
+	winner := models.Item{
+		ID:        "w",
+		UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Fields: []models.Field{
+			{Label: "security question", Value: "Rex"},
+			{Label: "recovery codes", Value: "aaaa"},
+			{Label: "pin", Value: "12"},
+			{Label: "hint", Value: "short"},
+		},
+	}
+	loser := models.Item{
+		ID:        "l",
+		UpdatedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Fields: []models.Field{
+			{Label: "security question", Value: "Fido"},
+			{Label: "recovery codes", Value: "bbbb"},
+			{Label: "pin", Value: "3456"},
+			{Label: "hint", Value: "a much longer hint"},
+			{Label: "legacy id", Value: "old"},
+		},
+	}
+	policy := &MergePolicy{Labels: map[string]FieldAction{
+		"security question": ActionKeepWinner,
+		"recovery codes":    ActionConcatenate,
+		"pin":               ActionKeepNewest,
+		"hint":              ActionKeepLongest,
+		"legacy id":         ActionDrop,
+	}}
+
+	merged, err := CalculateMergeWithPolicy(winner, loser, policy)
+	if err != nil {
+		t.Fatalf("CalculateMergeWithPolicy returned error: %v", err)
+	}
+
+	expected := map[string]string{
+		"security question": "Rex",
+		"recovery codes":    "aaaa\nbbbb",
+		"pin":               "3456",
+		"hint":              "a much longer hint",
+	}
+	if len(merged.Fields) != len(expected) {
+		t.Fatalf("expected %d fields and no conflicts, got %+v", len(expected), merged.Fields)
+	}
+	for _, field := range merged.Fields {
+		if field.Section != nil {
+			t.Errorf("field %q should not be archived", field.Label)
+		}
+		if want := expected[field.Label]; field.Value != want {
+			t.Errorf("field %q = %q, expected %q", field.Label, field.Value, want)
+		}
+	}
+
+	// Winner fields are never modified in place
+	if winner.Fields[1].Value != "aaaa" {
+		t.Fatalf("winner was mutated: %+v", winner.Fields)
+	}
+
+	// Concatenating a value already present is a no-op
+	again, _ := CalculateMergeWithPolicy(merged, loser, policy)
+	if got := again.Fields[1].Value; got != "aaaa\nbbbb" {
+		t.Fatalf("expected concatenation to be idempotent, got %q", got)
+	}
+}
@@ -0,0 +1,170 @@
+package items
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"1merge/internal/models"
+)
+
+// mergeHistorySectionID identifies the section listing the items a winner absorbed.
+const mergeHistorySectionID = "merge_history"
+
+// mergedFromSectionPrefix starts the ID of the sections holding fields added from losers.
+const mergedFromSectionPrefix = "merged_from_"
+
+// sourceSuffix matches the source list that addProvenance appends to section labels.
+var sourceSuffix = regexp.MustCompile(` \(from .*\)$`)
+
+// Provenance asks ApplyMergeWithOptions to record where the merged item came from.
+type Provenance struct {
+	// RunID identifies the 1merge run, see NewRunID.
+	RunID string
+	// Sources maps field indexes of the merged item to the items holding that value, as in
+	// MergeResult.Sources. Fields whose sources do not include the winner came from a loser.
+	Sources map[int][]string
+}
+
+// NewRunID returns an identifier for a 1merge run, made of the start time and a random suffix,
+// e.g. "20240115T093000Z-1a2b3c4d".
+func NewRunID() string {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return time.Now().UTC().Format("20060102T150405Z")
+	}
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+}
+
+// addProvenance returns a copy of the merged winner with a "Merge History" entry for each loser
+// and with every field added from a loser labelled with its source:
+//   - loose fields are moved into a section labelled e.g. `Merged from "Old Login"`
+//   - sections the merge put them in, such as "Archived Conflicts", get a `(from "Old Login")` suffix
+//
+// Loose fields with a purpose (username, password, notes) stay where 1Password expects them.
+func addProvenance(winner models.Item, losers []models.Item, provenance Provenance) models.Item {
+	titles := make(map[string]string, len(losers))
+	for _, loser := range losers {
+		titles[loser.ID] = itemSourceName(loser)
+	}
+
+	fields := make([]models.Field, len(winner.Fields))
+	copy(fields, winner.Fields)
+
+	sectionSources := make(map[string][]string)
+	for i := range fields {
+		ids := loserSources(provenance.Sources[i], winner.ID, titles)
+		if len(ids) == 0 {
+			continue
+		}
+
+		if fields[i].Section == nil {
+			if fields[i].Purpose != "" || isNotesField(fields[i]) {
+				continue
+			}
+			fields[i].Section = &models.Section{
+				ID:    mergedFromSectionPrefix + strings.Join(ids, "_"),
+				Label: "Merged from " + quotedSourceList(ids, titles),
+			}
+			continue
+		}
+
+		sectionID := fields[i].Section.ID
+		for _, id := range ids {
+			if !containsFold(sectionSources[sectionID], id) {
+				sectionSources[sectionID] = append(sectionSources[sectionID], id)
+			}
+		}
+	}
+
+	// All fields in a section share its label, so each section lists the sources of all its loser fields
+	for i := range fields {
+		if fields[i].Section == nil {
+			continue
+		}
+		ids, ok := sectionSources[fields[i].Section.ID]
+		if !ok {
+			continue
+		}
+		section := *fields[i].Section
+		label := section.Label
+		if label == "" {
+			label = defaultSectionLabel(section.ID)
+		}
+		section.Label = sourceSuffix.ReplaceAllString(label, "") + " (from " + quotedSourceList(ids, titles) + ")"
+		fields[i].Section = &section
+	}
+
+	// Record each absorbed item in the "Merge History" section
+	historySection := &models.Section{ID: mergeHistorySectionID, Label: "Merge History"}
+	for _, loser := range losers {
+		fields = append(fields, models.Field{
+			Type:    "STRING",
+			Label:   itemSourceName(loser),
+			Value:   mergeHistoryEntry(loser, provenance.RunID),
+			Section: historySection,
+		})
+	}
+
+	winner.Fields = fields
+	return winner
+}
+
+// mergeHistoryEntry describes an absorbed item in one line.
+func mergeHistoryEntry(loser models.Item, runID string) string {
+	vaultName := loser.Vault.Name
+	if vaultName == "" {
+		vaultName = loser.Vault.ID
+	}
+	entry := fmt.Sprintf("ID %s, vault %q, updated %s", loser.ID, vaultName, loser.UpdatedAt.UTC().Format(time.RFC3339))
+	if runID != "" {
+		entry += ", 1merge run " + runID
+	}
+	return entry
+}
+
+// loserSources returns the IDs of losers among a field's sources, or nil if the winner had the value.
+func loserSources(sources []string, winnerID string, titles map[string]string) []string {
+	var ids []string
+	for _, id := range sources {
+		if id == winnerID {
+			return nil
+		}
+		if _, ok := titles[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// quotedSourceList formats item names for a section label, e.g. `"Old Login", "Work Login"`.
+func quotedSourceList(ids []string, titles map[string]string) string {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = fmt.Sprintf("%q", titles[id])
+	}
+	return strings.Join(names, ", ")
+}
+
+// itemSourceName names an item in provenance labels: its title, or its ID if it has none.
+func itemSourceName(item models.Item) string {
+	if item.Title == "" {
+		return item.ID
+	}
+	return item.Title
+}
+
+// defaultSectionLabel returns the label shown for the sections the merge creates without one.
+func defaultSectionLabel(sectionID string) string {
+	switch sectionID {
+	case "archived_conflicts":
+		return "Archived Conflicts"
+	case previousPasswordsSectionID:
+		return "Previous Passwords"
+	default:
+		return sectionID
+	}
+}
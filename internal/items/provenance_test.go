@@ -0,0 +1,94 @@
+package items
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"1merge/internal/models"
+)
+
+func TestAddProvenance(t *testing.T) {
+	winner := models.Item{
+		ID: "winner",
+		Fields: []models.Field{
+			{ID: "username", Type: "STRING", Purpose: "USERNAME", Label: "username", Value: "me"},
+			{ID: "password", Type: "CONCEALED", Purpose: "PASSWORD", Label: "password", Value: "new"},
+		},
+	}
+	losers := []models.Item{
+		{
+			ID:        "loser1",
+			Title:     "Old Login",
+			Vault:     models.Vault{ID: "v1", Name: "Private"},
+			UpdatedAt: time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC),
+			Fields: []models.Field{
+				{ID: "password", Type: "CONCEALED", Purpose: "PASSWORD", Label: "password", Value: "old"},
+				{Type: "STRING", Label: "pin", Value: "1234"},
+			},
+		},
+		{
+			ID:        "loser2",
+			Vault:     models.Vault{ID: "v2"},
+			UpdatedAt: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+			Fields: []models.Field{
+				{ID: "password", Type: "CONCEALED", Purpose: "PASSWORD", Label: "password", Value: "old"},
+			},
+		},
+	}
+
+	result, err := MergeAll(winner, losers, nil)
+	if err != nil {
+		t.Fatalf("MergeAll returned error: %v", err)
+	}
+	got := addProvenance(result.Item, losers, Provenance{RunID: "run-1", Sources: result.Sources})
+
+	sections := make(map[string]string)
+	var history []models.Field
+	for _, field := range got.Fields {
+		if field.Section == nil {
+			if field.Purpose == "" {
+				t.Errorf("loose field %q from a loser should have been moved into a section", field.Label)
+			}
+			continue
+		}
+		sections[field.Label] = field.Section.Label
+		if field.Section.ID == mergeHistorySectionID {
+			history = append(history, field)
+		}
+	}
+
+	if expected := `Merged from "Old Login"`; sections["pin"] != expected {
+		t.Errorf("pin section label = %q, expected %q", sections["pin"], expected)
+	}
+	if expected := `Archived Conflicts (from "Old Login", "loser2")`; sections["password"] != expected {
+		t.Errorf("conflict section label = %q, expected %q", sections["password"], expected)
+	}
+
+	if len(history) != 2 {
+		t.Fatalf("expected 2 merge history entries, got %+v", history)
+	}
+	expected := `ID loser1, vault "Private", updated 2024-01-15T09:30:00Z, 1merge run run-1`
+	if history[0].Label != "Old Login" || history[0].Value != expected {
+		t.Errorf("history entry = %q: %q, expected %q: %q", history[0].Label, history[0].Value, "Old Login", expected)
+	}
+	if !strings.Contains(history[1].Value, `vault "v2"`) {
+		t.Errorf("history entry should fall back to the vault ID, got %q", history[1].Value)
+	}
+
+	// A second merge replaces the source list rather than stacking suffixes
+	again := addProvenance(got, losers[:1], Provenance{Sources: map[int][]string{2: {"loser1"}}})
+	if label := again.Fields[2].Section.Label; label != `Archived Conflicts (from "Old Login")` {
+		t.Errorf("relabelled section = %q", label)
+	}
+}
+
+func TestNewRunID(t *testing.T) {
+	first, second := NewRunID(), NewRunID()
+	if first == second {
+		t.Fatalf("expected distinct run IDs, got %q twice", first)
+	}
+	if len(first) != len("20240115T093000Z-1a2b3c4d") {
+		t.Errorf("unexpected run ID format %q", first)
+	}
+}
@@ -0,0 +1,189 @@
+package items
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+
+	"1merge/internal/domain"
+	"1merge/internal/models"
+)
+
+// TitleStrategy selects the title of the merged item.
+type TitleStrategy string
+
+const (
+	// TitleWinner keeps the winner's title unchanged (the default).
+	TitleWinner TitleStrategy = "winner"
+	// TitleShortest uses the shortest clean title in the group.
+	TitleShortest TitleStrategy = "shortest"
+	// TitleCommon uses the clean title shared by most items in the group.
+	TitleCommon TitleStrategy = "common"
+	// TitleDomain derives the title from the winner's website, e.g. "Google" for accounts.google.com.
+	TitleDomain TitleStrategy = "domain"
+	// TitleAsk lets the user pick the title; the CLI records the choice in MergePolicy.TitleOverride.
+	TitleAsk TitleStrategy = "ask"
+)
+
+// validTitleStrategies lists every strategy accepted in a policy file or flag.
+var validTitleStrategies = map[TitleStrategy]bool{
+	TitleWinner:   true,
+	TitleShortest: true,
+	TitleCommon:   true,
+	TitleDomain:   true,
+	TitleAsk:      true,
+}
+
+// ParseTitleStrategy validates a strategy name; the empty string means TitleWinner.
+func ParseTitleStrategy(name string) (TitleStrategy, error) {
+	if name == "" {
+		return TitleWinner, nil
+	}
+	strategy := TitleStrategy(strings.ToLower(name))
+	if !validTitleStrategies[strategy] {
+		return "", fmt.Errorf("unknown title strategy %q (expected winner, shortest, common, domain or ask)", name)
+	}
+	return strategy, nil
+}
+
+var (
+	// titleNumericSuffix matches copy counters such as " (2)" or " [3]".
+	titleNumericSuffix = regexp.MustCompile(`\s*[(\[]\d+[)\]]\s*$`)
+	// titleLeftoverWords matches words left behind by imports and copies.
+	titleLeftoverWords = regexp.MustCompile(`(?i)(^|[\s\-–:])\(?(copy of|copy|imported)\)?($|[\s\-–:])`)
+	// genericTitles are titles that say nothing about the site once leftovers are removed.
+	genericTitles = map[string]bool{"": true, "login": true, "untitled": true, "untitled login": true, "password": true}
+)
+
+// CleanTitle removes import leftovers from a title: copy counters like "(2)" and the words
+// "Copy", "Copy of" and "Imported". Titles that are hostnames or generic (such as
+// "Login") are not useful names, and "" is returned for them.
+func CleanTitle(title string) string {
+	cleaned := strings.TrimSpace(title)
+	for {
+		previous := cleaned
+		cleaned = titleNumericSuffix.ReplaceAllString(cleaned, "")
+		cleaned = titleLeftoverWords.ReplaceAllString(cleaned, " ")
+		cleaned = strings.Trim(strings.Join(strings.Fields(cleaned), " "), " -–:")
+		if cleaned == previous {
+			break
+		}
+	}
+
+	if genericTitles[strings.ToLower(cleaned)] || isHostname(cleaned) {
+		return ""
+	}
+	return cleaned
+}
+
+// isHostname reports whether a title is just a hostname or URL, such as "accounts.google.com".
+func isHostname(title string) bool {
+	if strings.Contains(title, "://") {
+		return true
+	}
+	if strings.ContainsAny(title, " /") || !strings.Contains(title, ".") {
+		return false
+	}
+	if net.ParseIP(title) != nil {
+		return true
+	}
+	_, icann := publicsuffix.PublicSuffix(strings.ToLower(title))
+	return icann
+}
+
+// TitleCandidates returns the distinct clean titles of a group, in group order.
+// Titles differing only in case count as one; the first spelling wins.
+func TitleCandidates(groupItems []models.Item) []string {
+	var candidates []string
+	seen := make(map[string]bool)
+	for _, item := range groupItems {
+		title := CleanTitle(item.Title)
+		if title == "" || seen[strings.ToLower(title)] {
+			continue
+		}
+		seen[strings.ToLower(title)] = true
+		candidates = append(candidates, title)
+	}
+	return candidates
+}
+
+// DomainTitle derives a title from an item's website, e.g. "Google" for https://accounts.google.com.
+// It returns "" if the item has no usable URL.
+func DomainTitle(item models.Item) string {
+	baseDomain, err := domain.GetBaseDomain(getPrimaryURL(item))
+	if err != nil || net.ParseIP(baseDomain) != nil {
+		return ""
+	}
+
+	name := baseDomain
+	if suffix, _ := publicsuffix.PublicSuffix(baseDomain); suffix != baseDomain {
+		name = strings.TrimSuffix(baseDomain, "."+suffix)
+	}
+	if name == "" {
+		return ""
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// ChooseTitle returns the title for the item merged from groupItems, using the policy's title
+// strategy. Strategies that find no clean title fall back to the winner's title.
+func ChooseTitle(groupItems []models.Item, winner models.Item, policy *MergePolicy) string {
+	if policy != nil && policy.TitleOverride != "" {
+		return policy.TitleOverride
+	}
+
+	var title string
+	switch policy.titleStrategy() {
+	case TitleShortest:
+		title = shortestTitle(TitleCandidates(groupItems))
+	case TitleCommon:
+		title = commonTitle(groupItems)
+	case TitleDomain:
+		title = DomainTitle(winner)
+	}
+
+	if title == "" {
+		return winner.Title
+	}
+	return title
+}
+
+// shortestTitle returns the shortest candidate; ties go to the earliest one.
+func shortestTitle(candidates []string) string {
+	sorted := append([]string(nil), candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len([]rune(sorted[i])) < len([]rune(sorted[j]))
+	})
+	if len(sorted) == 0 {
+		return ""
+	}
+	return sorted[0]
+}
+
+// commonTitle returns the clean title shared by most items; ties go to the shortest.
+func commonTitle(groupItems []models.Item) string {
+	counts := make(map[string]int)
+	for _, item := range groupItems {
+		if title := CleanTitle(item.Title); title != "" {
+			counts[strings.ToLower(title)]++
+		}
+	}
+
+	candidates := TitleCandidates(groupItems)
+	best := ""
+	for _, candidate := range candidates {
+		if best == "" {
+			best = candidate
+			continue
+		}
+		count, bestCount := counts[strings.ToLower(candidate)], counts[strings.ToLower(best)]
+		if count > bestCount || (count == bestCount && len([]rune(candidate)) < len([]rune(best))) {
+			best = candidate
+		}
+	}
+	return best
+}
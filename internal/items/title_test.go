@@ -0,0 +1,113 @@
+package items
+
+import (
+	"testing"
+
+	"1merge/internal/models"
+)
+
+func TestCleanTitle(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Google", "Google"},
+		{"Google (2)", "Google"},
+		{"Google [3]", "Google"},
+		{"Google - Copy", "Google"},
+		{"Copy of Google", "Google"},
+		{"Google (Copy) (2)", "Google"},
+		{"Imported Google", "Google"},
+		{"Imported Login", ""},
+		{"accounts.google.com (2)", ""},
+		{"https://google.com", ""},
+		{"192.168.1.1", ""},
+		{"Office 365", "Office 365"},
+		{"Copyright Office", "Copyright Office"},
+		{"Mr. Smith", "Mr. Smith"},
+		{"  ", ""},
+	}
+
+	for _, tt := range tests {
+		if got := CleanTitle(tt.input); got != tt.expected {
+			t.Errorf("CleanTitle(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestChooseTitle(t *testing.T) {
+	winner := models.Item{ID: "w", Title: "accounts.google.com (2)", URLs: []models.URL{{HRef: "https://accounts.google.com/login", Primary: true}}}
+	groupItems := []models.Item{
+		winner,
+		{ID: "a", Title: "Google Account"},
+		{ID: "b", Title: "Google Account (2)"},
+		{ID: "c", Title: "Google"},
+		{ID: "d", Title: "Imported Login"},
+	}
+
+	tests := []struct {
+		name     string
+		policy   *MergePolicy
+		items    []models.Item
+		expected string
+	}{
+		{name: "nil policy keeps winner", expected: "accounts.google.com (2)"},
+		{name: "winner", policy: &MergePolicy{Title: TitleWinner}, expected: "accounts.google.com (2)"},
+		{name: "shortest", policy: &MergePolicy{Title: TitleShortest}, expected: "Google"},
+		{name: "most common", policy: &MergePolicy{Title: TitleCommon}, expected: "Google Account"},
+		{name: "domain", policy: &MergePolicy{Title: TitleDomain}, expected: "Google"},
+		{name: "override", policy: (*MergePolicy)(nil).WithTitle(TitleAsk, "Work Google"), expected: "Work Google"},
+		{
+			name:     "no clean title falls back to winner",
+			policy:   &MergePolicy{Title: TitleShortest},
+			items:    []models.Item{winner, {ID: "d", Title: "Imported Login"}},
+			expected: "accounts.google.com (2)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := tt.items
+			if members == nil {
+				members = groupItems
+			}
+			if got := ChooseTitle(members, winner, tt.policy); got != tt.expected {
+				t.Errorf("ChooseTitle() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDomainTitle(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://accounts.google.com", "Google"},
+		{"https://www.bbc.co.uk/account", "Bbc"},
+		{"http://192.168.1.1", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		item := models.Item{URLs: []models.URL{{HRef: tt.url}}}
+		if tt.url == "" {
+			item.URLs = nil
+		}
+		if got := DomainTitle(item); got != tt.expected {
+			t.Errorf("DomainTitle(%q) = %q, expected %q", tt.url, got, tt.expected)
+		}
+	}
+}
+
+func TestParseTitleStrategy(t *testing.T) {
+	if strategy, err := ParseTitleStrategy(""); err != nil || strategy != TitleWinner {
+		t.Errorf("ParseTitleStrategy(\"\") = %q, %v", strategy, err)
+	}
+	if strategy, err := ParseTitleStrategy("Shortest"); err != nil || strategy != TitleShortest {
+		t.Errorf("ParseTitleStrategy(\"Shortest\") = %q, %v", strategy, err)
+	}
+	if _, err := ParseTitleStrategy("longest"); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+}
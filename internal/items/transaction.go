@@ -0,0 +1,126 @@
+package items
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"1merge/internal/models"
+)
+
+// ApplyState describes what a failed apply left behind in the vault.
+type ApplyState string
+
+const (
+	// StateUnchanged means the apply failed before anything in the vault was modified.
+	StateUnchanged ApplyState = "unchanged"
+	// StateRolledBack means the apply modified the vault, failed, and every change was undone.
+	StateRolledBack ApplyState = "rolled back"
+	// StatePartiallyMerged means the group could not be fully undone: either a rollback step
+	// failed, or some losers were already archived and the winner keeps the merged data.
+	StatePartiallyMerged ApplyState = "partially merged"
+)
+
+// ApplyError is returned by ApplyMergeWithOptions with Rollback enabled.
+// It wraps the original failure and records the final state of the group.
+type ApplyError struct {
+	ItemID string
+	State  ApplyState
+	// Err is the failure that triggered the rollback.
+	Err error
+	// RollbackErrs lists the compensating steps that failed, if any.
+	RollbackErrs []error
+	// NotArchived lists loser IDs left in the vault after some losers were already archived.
+	// Their data is in the winner, so they can be archived by hand.
+	NotArchived []string
+}
+
+func (e *ApplyError) Error() string {
+	switch e.State {
+	case StateUnchanged:
+		return fmt.Sprintf("%v (no changes were made)", e.Err)
+	case StateRolledBack:
+		return fmt.Sprintf("%v (changes to item %s were rolled back)", e.Err, e.ItemID)
+	default:
+		msg := fmt.Sprintf("%v (rollback incomplete, item %s is partially merged", e.Err, e.ItemID)
+		if len(e.RollbackErrs) > 0 {
+			msg += fmt.Sprintf(": %v", errors.Join(e.RollbackErrs...))
+		}
+		if len(e.NotArchived) > 0 {
+			msg += fmt.Sprintf("; it keeps the merged data, archive %s by hand", strings.Join(e.NotArchived, ", "))
+		}
+		return msg + ")"
+	}
+}
+
+func (e *ApplyError) Unwrap() error {
+	return e.Err
+}
+
+// mergeTransaction tracks the changes made by one apply so they can be undone on failure.
+type mergeTransaction struct {
+	winnerID string
+	loserIDs []string
+	// original is the winner as stored before the edit, nil when rollback is disabled.
+	original *models.Item
+	edited   bool
+	attached []string
+	archived []string
+}
+
+// fail handles an apply failure. Without rollback the error is returned unchanged;
+// otherwise the changes made so far are compensated and an *ApplyError is returned.
+func (tx *mergeTransaction) fail(err error, rollback bool) error {
+	if !rollback {
+		return err
+	}
+	if !tx.edited && len(tx.archived) == 0 {
+		return &ApplyError{ItemID: tx.winnerID, State: StateUnchanged, Err: err}
+	}
+	return tx.rollback(err)
+}
+
+// rollback undoes the apply. The op CLI cannot move an item out of the Archive, so once a loser
+// is archived the winner keeps the merged data, which holds everything from the archived losers,
+// and the losers not yet archived are reported in NotArchived. Otherwise copied attachments are
+// removed and the winner's original template is restored, attempting every step even if an
+// earlier one fails.
+func (tx *mergeTransaction) rollback(cause error) error {
+	applyErr := &ApplyError{ItemID: tx.winnerID, State: StateRolledBack, Err: cause}
+
+	if len(tx.archived) > 0 {
+		archived := make(map[string]bool, len(tx.archived))
+		for _, id := range tx.archived {
+			archived[id] = true
+		}
+		for _, id := range tx.loserIDs {
+			if !archived[id] {
+				applyErr.NotArchived = append(applyErr.NotArchived, id)
+			}
+		}
+		applyErr.State = StatePartiallyMerged
+		return applyErr
+	}
+
+	for _, name := range tx.attached {
+		if err := removeAttachment(tx.winnerID, name); err != nil {
+			applyErr.RollbackErrs = append(applyErr.RollbackErrs, err)
+		}
+	}
+
+	if tx.edited {
+		jsonBytes, err := json.MarshalIndent(tx.original, "", "  ")
+		if err == nil {
+			err = editItem(tx.winnerID, jsonBytes)
+		}
+		if err != nil {
+			applyErr.RollbackErrs = append(applyErr.RollbackErrs, fmt.Errorf("failed to restore item %s: %w", tx.winnerID, err))
+		}
+	}
+
+	if len(applyErr.RollbackErrs) > 0 {
+		applyErr.State = StatePartiallyMerged
+	}
+	return applyErr
+}
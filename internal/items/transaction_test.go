@@ -0,0 +1,164 @@
+package items
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"1merge/internal/models"
+	"1merge/internal/op"
+)
+
+// opItemCommands are the "op item" subcommands the real CLI provides and 1merge uses.
+var opItemCommands = map[string]bool{"list": true, "get": true, "edit": true, "delete": true}
+
+// failingOpClient fails the command whose "verb id" (e.g. "delete loser2") matches a key in failOn.
+// Like the real CLI, it rejects "op item" subcommands that do not exist. stored holds the
+// template of the last successful "op item edit --template".
+type failingOpClient struct {
+	calls     []string
+	failOn    map[string]error
+	getOutput []byte
+	stored    string
+}
+
+func (f *failingOpClient) RunOpCmd(args ...string) ([]byte, error) {
+	f.calls = append(f.calls, strings.Join(args, " "))
+	if len(args) >= 2 && args[0] == "item" && !opItemCommands[args[1]] {
+		return nil, fmt.Errorf("unknown command %q for \"op item\"", args[1])
+	}
+	if len(args) >= 3 {
+		if err, ok := f.failOn[args[1]+" "+args[2]]; ok {
+			return nil, err
+		}
+	}
+	if len(args) >= 2 && args[1] == "get" {
+		return f.getOutput, nil
+	}
+	if len(args) == 5 && args[1] == "edit" && args[3] == "--template" {
+		data, err := os.ReadFile(args[4])
+		if err != nil {
+			return nil, err
+		}
+		f.stored = string(data)
+	}
+	return nil, nil
+}
+
+func TestApplyMergeWithOptions_Rollback(t *testing.T) {
+	original := createTestItem("winner", "Winner", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC))
+	originalJSON, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("failed to marshal item: %v", err)
+	}
+
+	merged := original
+	merged.URLs = append(merged.URLs, models.URL{HRef: "https://login.example.com"})
+	losers := []models.Item{
+		createTestItem("loser1", "Loser 1", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		createTestItem("loser2", "Loser 2", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)),
+		createTestItem("loser3", "Loser 3", time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)),
+	}
+
+	tests := []struct {
+		name          string
+		failOn        map[string]error
+		expectedState ApplyState
+		expectedCalls []string
+		notArchived   []string
+		// keepsMerge is whether the winner ends up with the merged data rather than its original
+		keepsMerge bool
+	}{
+		{
+			name:          "snapshot failure leaves vault unchanged",
+			failOn:        map[string]error{"get winner": errors.New("get failed")},
+			expectedState: StateUnchanged,
+			expectedCalls: []string{"item get winner"},
+		},
+		{
+			name:          "edit failure leaves vault unchanged",
+			failOn:        map[string]error{"edit winner": errors.New("edit failed")},
+			expectedState: StateUnchanged,
+			expectedCalls: []string{"item get winner", "item edit winner"},
+		},
+		{
+			name:          "first archive failure restores winner",
+			failOn:        map[string]error{"delete loser1": errors.New("archive failed")},
+			expectedState: StateRolledBack,
+			expectedCalls: []string{
+				"item get winner",
+				"item edit winner",
+				"item delete loser1",
+				"item edit winner",
+			},
+		},
+		{
+			// Restoring the winner would lose the data absorbed from the archived losers
+			name:          "later archive failure keeps the merged winner",
+			failOn:        map[string]error{"delete loser2": errors.New("archive failed")},
+			expectedState: StatePartiallyMerged,
+			expectedCalls: []string{
+				"item get winner",
+				"item edit winner",
+				"item delete loser1",
+				"item delete loser2",
+			},
+			notArchived: []string{"loser2", "loser3"},
+			keepsMerge:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &failingOpClient{failOn: tt.failOn, getOutput: originalJSON}
+			SetOpClient(client)
+			t.Cleanup(func() { SetOpClient(op.DefaultClient) })
+
+			err := ApplyMergeWithOptions(merged, losers, ApplyOptions{Rollback: true})
+
+			var applyErr *ApplyError
+			if !errors.As(err, &applyErr) {
+				t.Fatalf("expected *ApplyError, got %v", err)
+			}
+			if applyErr.State != tt.expectedState {
+				t.Fatalf("expected state %q, got %q (%v)", tt.expectedState, applyErr.State, err)
+			}
+			if !strings.Contains(err.Error(), string(tt.expectedState)) && tt.expectedState != StateUnchanged {
+				t.Errorf("error message %q should mention the final state", err.Error())
+			}
+			if strings.Join(applyErr.NotArchived, ",") != strings.Join(tt.notArchived, ",") {
+				t.Errorf("expected not archived %v, got %v", tt.notArchived, applyErr.NotArchived)
+			}
+			if hasMerge := strings.Contains(client.stored, "login.example.com"); hasMerge != tt.keepsMerge {
+				t.Errorf("winner keeps merged data = %v, expected %v", hasMerge, tt.keepsMerge)
+			}
+
+			// Ignore the temp file path in edit calls
+			calls := make([]string, len(client.calls))
+			for i, call := range client.calls {
+				calls[i] = strings.SplitN(call, " --", 2)[0]
+			}
+			if strings.Join(calls, "\n") != strings.Join(tt.expectedCalls, "\n") {
+				t.Fatalf("unexpected op calls:\n%s\nexpected:\n%s", strings.Join(calls, "\n"), strings.Join(tt.expectedCalls, "\n"))
+			}
+		})
+	}
+}
+
+func TestApplyMergeWithOptions_RollbackPreservesCause(t *testing.T) {
+	client := &failingOpClient{
+		failOn:    map[string]error{"delete loser1": &op.Error{Kind: op.KindAuthExpired, Err: errors.New("exit status 1")}},
+		getOutput: []byte(`{"id":"winner"}`),
+	}
+	SetOpClient(client)
+	t.Cleanup(func() { SetOpClient(op.DefaultClient) })
+
+	err := ApplyMergeWithOptions(models.Item{ID: "winner"}, []models.Item{{ID: "loser1"}}, ApplyOptions{Rollback: true})
+	if !op.IsAuthExpired(err) {
+		t.Fatalf("expected auth-expired cause to be preserved, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package items
+
+import (
+	"fmt"
+	"strings"
+
+	"1merge/internal/models"
+)
+
+// VerificationError is returned by ApplyMergeWithOptions when the edited winner read back
+// from 1Password does not contain everything the computed merge expected.
+type VerificationError struct {
+	ItemID      string
+	Differences []string
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("verification of item %s failed, losers were not archived:\n  - %s",
+		e.ItemID, strings.Join(e.Differences, "\n  - "))
+}
+
+// VerifyMerge compares the computed merge (expected) with the item stored in 1Password (actual)
+// and returns a description of every difference found. An empty result means the edit landed.
+// Server-generated values are ignored: field IDs, timestamps, vault details, extra fields the
+// server adds on its own, and values of fields the template left empty.
+func VerifyMerge(expected models.Item, actual models.Item) []string {
+	var differences []string
+
+	if expected.Title != actual.Title {
+		differences = append(differences, fmt.Sprintf("title is %q, expected %q", actual.Title, expected.Title))
+	}
+
+	actualFields := make(map[string][]models.Field)
+	for _, field := range actual.Fields {
+		key := fieldKey(field)
+		actualFields[key] = append(actualFields[key], field)
+	}
+
+	for _, field := range expected.Fields {
+		candidates := actualFields[fieldKey(field)]
+		if len(candidates) == 0 {
+			differences = append(differences, fmt.Sprintf("field %q%s is missing", field.Label, sectionSuffix(field)))
+			continue
+		}
+		if field.Value == "" {
+			// The server may generate a value for fields left empty in the template
+			continue
+		}
+		found := false
+		for _, candidate := range candidates {
+			if candidate.Value == field.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			// Never include field values in the report, they may be secrets
+			differences = append(differences, fmt.Sprintf("field %q%s has a different value", field.Label, sectionSuffix(field)))
+		}
+	}
+
+	for _, tag := range expected.Tags {
+		if !containsFold(actual.Tags, tag) {
+			differences = append(differences, fmt.Sprintf("tag %q is missing", tag))
+		}
+	}
+
+	for _, url := range expected.URLs {
+		if !urlExists(actual.URLs, url.HRef) {
+			differences = append(differences, fmt.Sprintf("URL %s is missing", url.HRef))
+		}
+	}
+
+	return differences
+}
+
+// fieldKey identifies a field by section and label, the parts of a field the server preserves.
+func fieldKey(field models.Field) string {
+	if field.Section == nil {
+		return "|" + field.Label
+	}
+	return field.Section.ID + "|" + field.Label
+}
+
+// sectionSuffix describes the section of a field for difference reports.
+func sectionSuffix(field models.Field) string {
+	if field.Section == nil {
+		return ""
+	}
+	return fmt.Sprintf(" in section %q", field.Section.ID)
+}
+
+// containsFold reports whether values contains s, ignoring case.
+func containsFold(values []string, s string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package items
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"1merge/internal/models"
+)
+
+func TestVerifyMerge(t *testing.T) {
+	expected := models.Item{
+		ID:    "winner",
+		Title: "Example",
+		Tags:  []string{"work", "1merge/merged"},
+		Fields: []models.Field{
+			{ID: "username", Type: "STRING", Label: "username", Value: "user"},
+			{ID: "password", Type: "CONCEALED", Label: "password", Value: "secret"},
+			{Type: "STRING", Label: "pin", Value: "1234", Section: &models.Section{ID: "archived_conflicts"}},
+			{Type: "CONCEALED", Label: "generated", Value: ""},
+		},
+		URLs: []models.URL{
+			{HRef: "https://example.com", Primary: true},
+			{HRef: "https://login.example.com"},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		actual        func() models.Item
+		expectedDiffs []string
+	}{
+		{
+			name: "identical item",
+			actual: func() models.Item {
+				return expected
+			},
+		},
+		{
+			name: "server-generated values are ignored",
+			actual: func() models.Item {
+				actual := expected
+				actual.Fields = []models.Field{
+					{ID: "username", Type: "STRING", Label: "username", Value: "user"},
+					{ID: "password", Type: "CONCEALED", Label: "password", Value: "secret"},
+					{ID: "abc123", Type: "STRING", Label: "pin", Value: "1234", Section: &models.Section{ID: "archived_conflicts"}},
+					{ID: "def456", Type: "CONCEALED", Label: "generated", Value: "Xy9!generated"},
+					{ID: "notesPlain", Type: "STRING", Label: "notesPlain", Value: ""},
+				}
+				return actual
+			},
+		},
+		{
+			name: "missing field, changed value and missing URL are reported",
+			actual: func() models.Item {
+				actual := expected
+				actual.Fields = []models.Field{
+					{ID: "username", Type: "STRING", Label: "username", Value: "user"},
+					{ID: "password", Type: "CONCEALED", Label: "password", Value: "other"},
+					{Type: "CONCEALED", Label: "generated", Value: "x"},
+				}
+				actual.URLs = expected.URLs[:1]
+				return actual
+			},
+			expectedDiffs: []string{
+				`field "password" has a different value`,
+				`field "pin" in section "archived_conflicts" is missing`,
+				"URL https://login.example.com is missing",
+			},
+		},
+		{
+			name: "field moved to another section is reported",
+			actual: func() models.Item {
+				actual := expected
+				actual.Fields = append([]models.Field{}, expected.Fields...)
+				actual.Fields[2] = models.Field{Type: "STRING", Label: "pin", Value: "1234"}
+				return actual
+			},
+			expectedDiffs: []string{`field "pin" in section "archived_conflicts" is missing`},
+		},
+		{
+			name: "missing tag is reported",
+			actual: func() models.Item {
+				actual := expected
+				actual.Tags = []string{"WORK"}
+				return actual
+			},
+			expectedDiffs: []string{`tag "1merge/merged" is missing`},
+		},
+		{
+			name: "title change is reported",
+			actual: func() models.Item {
+				actual := expected
+				actual.Title = "Other"
+				return actual
+			},
+			expectedDiffs: []string{`title is "Other", expected "Example"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs := VerifyMerge(expected, tt.actual())
+			if len(diffs) != len(tt.expectedDiffs) {
+				t.Fatalf("expected %d differences, got %d: %v", len(tt.expectedDiffs), len(diffs), diffs)
+			}
+			for _, want := range tt.expectedDiffs {
+				found := false
+				for _, diff := range diffs {
+					if diff == want {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("expected difference %q in %v", want, diffs)
+				}
+			}
+		})
+	}
+}
+
+func TestVerificationError(t *testing.T) {
+	var err error = &VerificationError{ItemID: "winner", Differences: []string{`field "password" is missing`}}
+
+	var verr *VerificationError
+	if !errors.As(err, &verr) {
+		t.Fatal("expected errors.As to match *VerificationError")
+	}
+	if !strings.Contains(err.Error(), "losers were not archived") || !strings.Contains(err.Error(), `field "password" is missing`) {
+		t.Fatalf("unexpected error message: %q", err.Error())
+	}
+}
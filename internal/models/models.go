@@ -9,7 +9,10 @@ type Item struct {
 	URLs                  []URL     `json:"urls"`
 	Vault                 Vault     `json:"vault"`
 	Category              string    `json:"category"`
+	Tags                  []string  `json:"tags,omitempty"`
+	Favorite              bool      `json:"favorite,omitempty"`
 	Fields                []Field   `json:"fields"`
+	Files                 []File    `json:"files,omitempty"`
 	UpdatedAt             time.Time `json:"updated_at"`
 	AdditionalInformation string    `json:"additional_information"`
 }
@@ -23,16 +26,34 @@ type URL struct {
 
 // Field represents a field within an item
 type Field struct {
-	ID      string   `json:"id"`
-	Type    string   `json:"type"`
-	Label   string   `json:"label"`
-	Value   string   `json:"value"`
-	Section *Section `json:"section,omitempty"`
+	ID              string           `json:"id"`
+	Type            string           `json:"type"`
+	Label           string           `json:"label"`
+	Value           string           `json:"value"`
+	Purpose         string           `json:"purpose,omitempty"`
+	Section         *Section         `json:"section,omitempty"`
+	PasswordDetails *PasswordDetails `json:"password_details,omitempty"`
+}
+
+// PasswordDetails holds the metadata 1Password keeps for password fields
+type PasswordDetails struct {
+	Strength string   `json:"strength,omitempty"`
+	History  []string `json:"history,omitempty"`
+}
+
+// File represents a document attached to an item
+type File struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Size        int64    `json:"size"`
+	ContentPath string   `json:"content_path,omitempty"`
+	Section     *Section `json:"section,omitempty"`
 }
 
 // Section represents a section grouping for fields
 type Section struct {
-	ID string `json:"id"`
+	ID    string `json:"id"`
+	Label string `json:"label,omitempty"`
 }
 
 // Vault represents vault information
@@ -12,8 +12,8 @@ type Client interface {
 	RunOpCmd(args ...string) ([]byte, error)
 }
 
-// DefaultClient executes real op CLI commands.
-var DefaultClient Client = commandClient{}
+// DefaultClient executes real op CLI commands, retrying transient failures with DefaultRetryPolicy.
+var DefaultClient Client = NewRetryClient(commandClient{}, DefaultRetryPolicy)
 
 type commandClient struct{}
 
@@ -22,7 +22,8 @@ func (commandClient) RunOpCmd(args ...string) ([]byte, error) {
 }
 
 // runOpCmdInternal executes an op CLI command and returns stdout bytes.
-// Used by RunOpCmd to handle command execution.
+// Used by RunOpCmd to handle command execution. Failures are returned as *Error,
+// classified from stderr so that callers can retry or stop as appropriate.
 func runOpCmdInternal(args ...string) ([]byte, error) {
 	cmd := exec.Command("op", args...)
 
@@ -34,9 +35,9 @@ func runOpCmdInternal(args ...string) ([]byte, error) {
 	if err != nil {
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) {
-			return nil, fmt.Errorf("op command failed: %w\nstderr: %s", err, stderr.String())
+			return nil, &Error{Kind: Classify(stderr.String()), Args: args, Stderr: stderr.String(), Err: err}
 		}
-		return nil, fmt.Errorf("op command failed: %w", err)
+		return nil, &Error{Kind: KindPermanent, Args: args, Err: err}
 	}
 
 	return stdout.Bytes(), nil
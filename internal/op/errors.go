@@ -0,0 +1,126 @@
+package op
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrorKind classifies a failed op CLI invocation so callers can decide how to react.
+type ErrorKind int
+
+const (
+	// KindPermanent errors will fail again if retried (bad arguments, missing items, permissions).
+	KindPermanent ErrorKind = iota
+	// KindRetryable errors are transient (rate limits, network hiccups) and may succeed on retry.
+	KindRetryable
+	// KindAuthExpired errors mean the op session is no longer valid and the user must sign in again.
+	KindAuthExpired
+)
+
+// String returns a short human-readable name for the error kind.
+func (k ErrorKind) String() string {
+	switch k {
+	case KindRetryable:
+		return "retryable"
+	case KindAuthExpired:
+		return "auth-expired"
+	default:
+		return "permanent"
+	}
+}
+
+// ErrSessionExpired is matched (via errors.Is) by any op error classified as KindAuthExpired.
+var ErrSessionExpired = errors.New("1Password CLI session expired; please run 'op signin' and re-run")
+
+// Error describes a failed op CLI command, including its stderr output and classification.
+type Error struct {
+	Kind   ErrorKind
+	Args   []string
+	Stderr string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("op command failed: %v", e.Err)
+	}
+	return fmt.Sprintf("op command failed: %v\nstderr: %s", e.Err, e.Stderr)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error matches target; auth-expired errors match ErrSessionExpired.
+func (e *Error) Is(target error) bool {
+	return target == ErrSessionExpired && e.Kind == KindAuthExpired
+}
+
+// retryableMarkers are lowercase stderr fragments that indicate a transient failure.
+var retryableMarkers = []string{
+	"rate limit",
+	"rate-limit",
+	"too many requests",
+	"timeout",
+	"timed out",
+	"connection reset",
+	"connection refused",
+	"no such host",
+	"network is unreachable",
+	"temporarily unavailable",
+	"service unavailable",
+	"bad gateway",
+	"gateway timeout",
+	"unexpected eof",
+	"tls handshake",
+}
+
+// authExpiredMarkers are lowercase stderr fragments that indicate the op session is no longer valid.
+// They match op's session wording only: op also says "unauthorized" when an account lacks
+// permission for a vault or item, which signing in again cannot fix.
+var authExpiredMarkers = []string{
+	"session expired",
+	"session has expired",
+	"you are not currently signed in",
+	"you are not signed in",
+	"account is not signed in",
+	"invalid session",
+	"authorization prompt dismissed",
+}
+
+// Classify inspects op stderr output and returns the matching ErrorKind.
+// Authentication problems take precedence over transient ones, since retrying cannot fix them.
+func Classify(stderr string) ErrorKind {
+	lower := strings.ToLower(stderr)
+	for _, marker := range authExpiredMarkers {
+		if strings.Contains(lower, marker) {
+			return KindAuthExpired
+		}
+	}
+	for _, marker := range retryableMarkers {
+		if strings.Contains(lower, marker) {
+			return KindRetryable
+		}
+	}
+	return KindPermanent
+}
+
+// KindOf returns the ErrorKind of err, or KindPermanent if err is not an op error.
+func KindOf(err error) ErrorKind {
+	var opErr *Error
+	if errors.As(err, &opErr) {
+		return opErr.Kind
+	}
+	return KindPermanent
+}
+
+// IsRetryable reports whether err is a transient op failure worth retrying.
+func IsRetryable(err error) bool {
+	return KindOf(err) == KindRetryable
+}
+
+// IsAuthExpired reports whether err means the op session has expired.
+func IsAuthExpired(err error) bool {
+	return errors.Is(err, ErrSessionExpired)
+}
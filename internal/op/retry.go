@@ -0,0 +1,111 @@
+package op
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls how RetryClient retries transient failures.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// BaseDelay is the backoff ceiling for the first retry; it doubles on every further retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff ceiling.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used by DefaultClient.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// RetryClient wraps another Client and retries retryable errors with jittered exponential backoff.
+// Permanent and auth-expired errors are returned immediately. It is safe for concurrent use:
+// the backoff is shared, so when one caller hits a rate limit every caller waits it out.
+type RetryClient struct {
+	inner  Client
+	policy RetryPolicy
+
+	mu         sync.Mutex
+	pauseUntil time.Time
+
+	// sleep, now and jitter are overridden in tests.
+	sleep  func(time.Duration)
+	now    func() time.Time
+	jitter func() float64
+}
+
+// NewRetryClient returns a RetryClient that delegates to inner using the given policy.
+func NewRetryClient(inner Client, policy RetryPolicy) *RetryClient {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	return &RetryClient{
+		inner:  inner,
+		policy: policy,
+		sleep:  time.Sleep,
+		now:    time.Now,
+		jitter: rand.Float64,
+	}
+}
+
+// RunOpCmd runs the command through the wrapped client, retrying transient failures.
+func (c *RetryClient) RunOpCmd(args ...string) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt < c.policy.MaxAttempts; attempt++ {
+		c.waitForPause()
+
+		output, err := c.inner.RunOpCmd(args...)
+		if err == nil {
+			return output, nil
+		}
+		lastErr = err
+
+		if !IsRetryable(err) {
+			return nil, err
+		}
+		if attempt+1 < c.policy.MaxAttempts {
+			c.pause(c.backoff(attempt + 1))
+		}
+	}
+	return nil, lastErr
+}
+
+// pause holds back every caller of this client for at least d.
+func (c *RetryClient) pause(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	until := c.now().Add(d)
+	if until.After(c.pauseUntil) {
+		c.pauseUntil = until
+	}
+}
+
+// waitForPause sleeps until any backoff set by this or another caller has elapsed.
+func (c *RetryClient) waitForPause() {
+	c.mu.Lock()
+	wait := c.pauseUntil.Sub(c.now())
+	c.mu.Unlock()
+
+	if wait > 0 {
+		c.sleep(wait)
+	}
+}
+
+// backoff returns a "full jitter" delay for the given retry number (1-based):
+// a random duration between zero and min(MaxDelay, BaseDelay * 2^(retry-1)).
+func (c *RetryClient) backoff(retry int) time.Duration {
+	ceiling := c.policy.BaseDelay
+	for i := 1; i < retry && ceiling < c.policy.MaxDelay; i++ {
+		ceiling *= 2
+	}
+	if c.policy.MaxDelay > 0 && ceiling > c.policy.MaxDelay {
+		ceiling = c.policy.MaxDelay
+	}
+	return time.Duration(c.jitter() * float64(ceiling))
+}
@@ -0,0 +1,187 @@
+package op
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// scriptedClient returns the queued errors in order, then succeeds.
+type scriptedClient struct {
+	errs  []error
+	calls int
+}
+
+func (s *scriptedClient) RunOpCmd(_ ...string) ([]byte, error) {
+	s.calls++
+	if s.calls <= len(s.errs) {
+		return nil, s.errs[s.calls-1]
+	}
+	return []byte("ok"), nil
+}
+
+func newTestRetryClient(inner Client, policy RetryPolicy) (*RetryClient, *[]time.Duration) {
+	var slept []time.Duration
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewRetryClient(inner, policy)
+	c.now = func() time.Time { return clock }
+	c.sleep = func(d time.Duration) {
+		slept = append(slept, d)
+		clock = clock.Add(d)
+	}
+	c.jitter = func() float64 { return 1 }
+	return c, &slept
+}
+
+func opErr(stderr string) error {
+	return &Error{Kind: Classify(stderr), Stderr: stderr, Err: errors.New("exit status 1")}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		stderr   string
+		expected ErrorKind
+	}{
+		{"[ERROR] 2024/01/01 rate limit exceeded", KindRetryable},
+		{"Too Many Requests", KindRetryable},
+		{"dial tcp: lookup my.1password.com: no such host", KindRetryable},
+		{"read: connection reset by peer", KindRetryable},
+		{"context deadline exceeded (Client.Timeout exceeded)", KindRetryable},
+		{"[ERROR] You are not currently signed in. Please run `op signin`", KindAuthExpired},
+		{"session expired, sign in to create a new session", KindAuthExpired},
+		{"\"nonexistent\" isn't an item in the \"Private\" vault", KindPermanent},
+		{"[ERROR] 2024/01/01 unauthorized: You don't have the right permissions to access vault \"Shared\"", KindPermanent},
+		{"[ERROR] (403) Forbidden: unauthorized to edit item abc123", KindPermanent},
+		{"", KindPermanent},
+	}
+
+	for _, tt := range tests {
+		if got := Classify(tt.stderr); got != tt.expected {
+			t.Errorf("Classify(%q) = %s, expected %s", tt.stderr, got, tt.expected)
+		}
+	}
+}
+
+func TestErrorMatching(t *testing.T) {
+	authErr := fmt.Errorf("failed to edit item: %w", opErr("You are not currently signed in"))
+	if !IsAuthExpired(authErr) {
+		t.Error("expected wrapped auth error to match ErrSessionExpired")
+	}
+	if IsRetryable(authErr) {
+		t.Error("auth error must not be retryable")
+	}
+
+	rateErr := fmt.Errorf("failed to fetch: %w", opErr("rate limit exceeded"))
+	if !IsRetryable(rateErr) {
+		t.Error("expected wrapped rate-limit error to be retryable")
+	}
+	if IsAuthExpired(rateErr) {
+		t.Error("rate-limit error must not match ErrSessionExpired")
+	}
+
+	if KindOf(errors.New("plain")) != KindPermanent {
+		t.Error("non-op errors should be permanent")
+	}
+}
+
+func TestRetryClient_RetriesTransientErrors(t *testing.T) {
+	inner := &scriptedClient{errs: []error{opErr("rate limit exceeded"), opErr("connection reset by peer")}}
+	client, slept := newTestRetryClient(inner, RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: time.Minute})
+
+	out, err := client.RunOpCmd("item", "list")
+	if err != nil {
+		t.Fatalf("RunOpCmd returned error: %v", err)
+	}
+	if string(out) != "ok" {
+		t.Fatalf("expected output %q, got %q", "ok", out)
+	}
+	if inner.calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", inner.calls)
+	}
+
+	expected := []time.Duration{time.Second, 2 * time.Second}
+	if len(*slept) != len(expected) {
+		t.Fatalf("expected %d sleeps, got %v", len(expected), *slept)
+	}
+	for i, d := range expected {
+		if (*slept)[i] != d {
+			t.Errorf("sleep %d = %v, expected %v", i, (*slept)[i], d)
+		}
+	}
+}
+
+func TestRetryClient_GivesUpAfterMaxAttempts(t *testing.T) {
+	rateErr := opErr("rate limit exceeded")
+	inner := &scriptedClient{errs: []error{rateErr, rateErr, rateErr, rateErr}}
+	client, _ := newTestRetryClient(inner, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+
+	_, err := client.RunOpCmd("item", "list")
+	if !errors.Is(err, rateErr) {
+		t.Fatalf("expected last rate-limit error, got %v", err)
+	}
+	if inner.calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", inner.calls)
+	}
+}
+
+func TestRetryClient_DoesNotRetryPermanentOrAuthErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"permanent", opErr("isn't an item in the vault")},
+		{"auth expired", opErr("You are not currently signed in")},
+		{"non-op error", errors.New("boom")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &scriptedClient{errs: []error{tt.err}}
+			client, slept := newTestRetryClient(inner, DefaultRetryPolicy)
+
+			_, err := client.RunOpCmd("item", "get", "x")
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected original error, got %v", err)
+			}
+			if inner.calls != 1 || len(*slept) != 0 {
+				t.Fatalf("expected a single attempt without sleeping, got %d calls and %v sleeps", inner.calls, *slept)
+			}
+		})
+	}
+}
+
+func TestRetryClient_BackoffIsCapped(t *testing.T) {
+	client, _ := newTestRetryClient(&scriptedClient{}, RetryPolicy{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: 5 * time.Second})
+
+	if got := client.backoff(10); got != 5*time.Second {
+		t.Fatalf("backoff(10) = %v, expected cap of 5s", got)
+	}
+
+	client.jitter = func() float64 { return 0.5 }
+	if got := client.backoff(2); got != time.Second {
+		t.Fatalf("backoff(2) with half jitter = %v, expected 1s", got)
+	}
+}
+
+func TestRetryClient_BackoffIsSharedBetweenCallers(t *testing.T) {
+	client, slept := newTestRetryClient(&scriptedClient{}, DefaultRetryPolicy)
+
+	// Another worker hit a rate limit and paused the client
+	client.pause(3 * time.Second)
+
+	if _, err := client.RunOpCmd("item", "get", "x"); err != nil {
+		t.Fatalf("RunOpCmd returned error: %v", err)
+	}
+	if len(*slept) != 1 || (*slept)[0] != 3*time.Second {
+		t.Fatalf("expected caller to wait out the shared 3s pause, slept %v", *slept)
+	}
+
+	// Once the pause has elapsed, calls go straight through
+	if _, err := client.RunOpCmd("item", "get", "y"); err != nil {
+		t.Fatalf("RunOpCmd returned error: %v", err)
+	}
+	if len(*slept) != 1 {
+		t.Fatalf("expected no further waiting, slept %v", *slept)
+	}
+}
@@ -0,0 +1,50 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+//go:embed report.html.tmpl
+var htmlTemplate string
+
+// maxChartRows limits the domain chart to the domains with the most duplicates.
+const maxChartRows = 15
+
+var pageTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"date": func(t time.Time) string {
+		if t.IsZero() {
+			return "unknown"
+		}
+		return t.Format("2006-01-02")
+	},
+	"top": func(counts []Count) []Count {
+		if len(counts) > maxChartRows {
+			return counts[:maxChartRows]
+		}
+		return counts
+	},
+	"percent": func(n int, counts []Count) template.CSS {
+		max := 0
+		for _, count := range counts {
+			if count.Items > max {
+				max = count.Items
+			}
+		}
+		if max == 0 {
+			return "width: 0%"
+		}
+		return template.CSS(fmt.Sprintf("width: %.1f%%", float64(n)*100/float64(max)))
+	},
+}).Parse(htmlTemplate))
+
+// WriteHTML writes the report as a self-contained HTML page with no external resources.
+func WriteHTML(w io.Writer, report Report) error {
+	if err := pageTemplate.Execute(w, report); err != nil {
+		return fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package report
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"1merge/internal/items"
+	"1merge/internal/models"
+)
+
+// Report is a snapshot of the duplicates in a vault. It holds titles, vault names and dates,
+// but never usernames or field values, so it can be shared with people without vault access.
+type Report struct {
+	GeneratedAt time.Time
+	// Vault is the vault that was scanned; "" means the default vault.
+	Vault          string
+	TotalItems     int
+	RedundantItems int
+	Conflicts      int
+	Groups         []Group
+	// Domains and Vaults count duplicate items, most duplicates first.
+	Domains []Count
+	Vaults  []Count
+}
+
+// Group is one duplicate group in a Report.
+type Group struct {
+	Domain string
+	Items  []Item
+	// WinnerTitle is the title of the item that would survive a merge; "" if the group cannot be merged.
+	WinnerTitle string
+	// Conflicts is the number of fields that would be moved to "Archived Conflicts".
+	Conflicts int
+	// Problem explains why the group cannot be merged as-is, e.g. several passkeys.
+	Problem string
+}
+
+// Item is a member of a duplicate group.
+type Item struct {
+	Title     string
+	Vault     string
+	UpdatedAt time.Time
+	Winner    bool
+}
+
+// Count is the number of duplicate items for a domain or vault.
+type Count struct {
+	Name  string
+	Items int
+}
+
+// Build creates a report from duplicate groups keyed as in items.GroupDuplicates. Groups should
+// hold full item details; groups listed in failed could not be read and are reported as such,
+// without the error text.
+// Conflict counts are computed with the given merge policy (nil archives every conflict).
+func Build(groups map[string][]models.Item, failed map[string]error, totalItems int, vault string, policy *items.MergePolicy) Report {
+	report := Report{GeneratedAt: time.Now().UTC(), Vault: vault, TotalItems: totalItems}
+	domainCounts := make(map[string]int)
+	vaultCounts := make(map[string]int)
+
+	for key, groupItems := range groups {
+		domain, _, _ := strings.Cut(key, "|")
+		group := Group{Domain: domain}
+		report.RedundantItems += len(groupItems) - 1
+		domainCounts[domain] += len(groupItems)
+
+		winnerID := ""
+		if _, ok := failed[key]; ok {
+			// The error is op output that may name items and the account, so it is not shared
+			group.Problem = "Item details could not be read"
+		} else if winner, err := items.SelectWinnerForMerge(groupItems); err != nil {
+			group.Problem = "Several items hold passkeys, which cannot be merged"
+		} else {
+			winnerID = winner.ID
+			group.WinnerTitle = winner.Title
+			group.Conflicts = conflictCount(winner, groupItems, policy)
+			report.Conflicts += group.Conflicts
+		}
+
+		for _, item := range groupItems {
+			vaultName := vaultName(item)
+			vaultCounts[vaultName]++
+			group.Items = append(group.Items, Item{
+				Title:     item.Title,
+				Vault:     vaultName,
+				UpdatedAt: item.UpdatedAt,
+				Winner:    item.ID == winnerID,
+			})
+		}
+		sort.SliceStable(group.Items, func(i, j int) bool {
+			return group.Items[i].UpdatedAt.After(group.Items[j].UpdatedAt)
+		})
+
+		report.Groups = append(report.Groups, group)
+	}
+
+	sort.SliceStable(report.Groups, func(i, j int) bool {
+		if report.Groups[i].Domain != report.Groups[j].Domain {
+			return report.Groups[i].Domain < report.Groups[j].Domain
+		}
+		return len(report.Groups[i].Items) > len(report.Groups[j].Items)
+	})
+	report.Domains = sortedCounts(domainCounts)
+	report.Vaults = sortedCounts(vaultCounts)
+
+	return report
+}
+
+// conflictCount merges the group and counts the fields that end up in "Archived Conflicts".
+func conflictCount(winner models.Item, groupItems []models.Item, policy *items.MergePolicy) int {
+	var losers []models.Item
+	for _, item := range groupItems {
+		if item.ID != winner.ID {
+			losers = append(losers, item)
+		}
+	}
+
+	result, err := items.MergeAll(winner, losers, policy)
+	if err != nil {
+		return 0
+	}
+	return items.ConflictCount(result.Item) - items.ConflictCount(winner)
+}
+
+// vaultName names an item's vault, falling back to its ID.
+func vaultName(item models.Item) string {
+	if item.Vault.Name != "" {
+		return item.Vault.Name
+	}
+	if item.Vault.ID != "" {
+		return item.Vault.ID
+	}
+	return "(unknown vault)"
+}
+
+// sortedCounts orders counts by size, then name.
+func sortedCounts(counts map[string]int) []Count {
+	sorted := make([]Count, 0, len(counts))
+	for name, n := range counts {
+		sorted = append(sorted, Count{Name: name, Items: n})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Items != sorted[j].Items {
+			return sorted[i].Items > sorted[j].Items
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
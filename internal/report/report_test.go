@@ -0,0 +1,88 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"1merge/internal/models"
+)
+
+func testGroups() map[string][]models.Item {
+	private := models.Vault{ID: "v1", Name: "Private"}
+	work := models.Vault{ID: "v2", Name: "Work"}
+	return map[string][]models.Item{
+		"google.com|alice@example.com": {
+			{ID: "g1", Title: "Google <old>", Vault: private, UpdatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+				Fields: []models.Field{{Label: "password", Value: "hunter2"}}},
+			{ID: "g2", Title: "Google", Vault: work, UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				Fields: []models.Field{{Label: "password", Value: "correct horse"}}},
+			{ID: "g3", Title: "Google (2)", Vault: private, UpdatedAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		"amazon.com|alice@example.com": {
+			{ID: "a1", Title: "Amazon", Vault: private},
+			{ID: "a2", Title: "Amazon", Vault: private},
+		},
+		"bank.com|alice@example.com": {
+			{ID: "b1", Title: "Bank", Vault: work, Fields: []models.Field{{Type: "PASSKEY", Label: "passkey"}}},
+			{ID: "b2", Title: "Bank", Vault: work, Fields: []models.Field{{Type: "PASSKEY", Label: "passkey"}}},
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	groups := testGroups()
+	failed := map[string]error{"amazon.com|alice@example.com": errors.New(`"a1" isn't an item in account my.1password.com`)}
+
+	report := Build(groups, failed, 20, "Private", nil)
+
+	if report.TotalItems != 20 || report.RedundantItems != 4 || len(report.Groups) != 3 {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+
+	// Groups are ordered by domain
+	google := report.Groups[2]
+	if google.Domain != "google.com" || google.WinnerTitle != "Google" || google.Conflicts != 1 {
+		t.Fatalf("unexpected google group: %+v", google)
+	}
+	if !google.Items[0].Winner || google.Items[0].Vault != "Work" {
+		t.Errorf("expected newest item first and marked as winner, got %+v", google.Items)
+	}
+	if report.Groups[0].Problem == "" || report.Groups[1].Problem == "" {
+		t.Errorf("expected failed and passkey groups to report a problem, got %+v", report.Groups[:2])
+	}
+	if strings.Contains(report.Groups[0].Problem, "my.1password.com") {
+		t.Errorf("expected the op error to stay out of the report, got %q", report.Groups[0].Problem)
+	}
+
+	if report.Vaults[0] != (Count{Name: "Private", Items: 4}) || report.Vaults[1] != (Count{Name: "Work", Items: 3}) {
+		t.Errorf("unexpected vault counts: %+v", report.Vaults)
+	}
+	if report.Domains[0] != (Count{Name: "google.com", Items: 3}) {
+		t.Errorf("unexpected domain counts: %+v", report.Domains)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteHTML(&out, Build(testGroups(), nil, 20, "", nil)); err != nil {
+		t.Fatalf("WriteHTML returned error: %v", err)
+	}
+	page := out.String()
+
+	for _, secret := range []string{"hunter2", "correct horse", "alice@example.com"} {
+		if strings.Contains(page, secret) {
+			t.Errorf("report must not contain %q", secret)
+		}
+	}
+	for _, expected := range []string{"Google &lt;old&gt;", "width: 100.0%", "width: 66.7%", "default vault"} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("report is missing %q", expected)
+		}
+	}
+	if strings.Contains(page, "<script") || strings.Contains(page, "http") {
+		t.Error("report should be self-contained")
+	}
+}
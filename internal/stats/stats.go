@@ -0,0 +1,216 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"1merge/internal/items"
+	"1merge/internal/models"
+)
+
+// Stats summarises a vault before cleaning: how many duplicates there are, where they are and
+// how old the items are. It holds counts, domains and vault names only.
+type Stats struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	// Vault is the vault that was scanned; "" means the default vault.
+	Vault      string `json:"vault,omitempty"`
+	TotalItems int    `json:"total_items"`
+	// DuplicateGroups and DuplicateItems count the groups and all of their members;
+	// RedundantItems is what a full merge would archive, one less than each group's size.
+	DuplicateGroups int `json:"duplicate_groups"`
+	DuplicateItems  int `json:"duplicate_items"`
+	RedundantItems  int `json:"redundant_items"`
+	// NoURL and NoUsername count the items that cannot be grouped; an item missing both is in both.
+	NoURL      int `json:"no_url"`
+	NoUsername int `json:"no_username"`
+	// TopDomains lists the domains with the most redundant items.
+	TopDomains []Domain `json:"top_domains"`
+	// Vaults counts all items and duplicate items per vault, most items first.
+	Vaults []Vault `json:"vaults"`
+	// Ages counts items by time since their last update.
+	Ages []AgeBucket `json:"ages"`
+}
+
+// Domain is the duplicate count of one base domain.
+type Domain struct {
+	Domain    string `json:"domain"`
+	Groups    int    `json:"groups"`
+	Items     int    `json:"items"`
+	Redundant int    `json:"redundant"`
+}
+
+// Vault is the item count of one vault.
+type Vault struct {
+	Name           string `json:"name"`
+	Items          int    `json:"items"`
+	DuplicateItems int    `json:"duplicate_items"`
+}
+
+// AgeBucket counts the items last updated within a range of ages.
+type AgeBucket struct {
+	Label string `json:"label"`
+	Items int    `json:"items"`
+}
+
+// ageBuckets are the histogram ranges, each ending before the given age.
+var ageBuckets = []struct {
+	label         string
+	years, months int
+}{
+	{label: "under 1 month", months: 1},
+	{label: "1-6 months", months: 6},
+	{label: "6-12 months", years: 1},
+	{label: "1-2 years", years: 2},
+	{label: "2-5 years", years: 5},
+}
+
+// Build computes statistics for the items fetched from a vault and the duplicate groups found among
+// them, keyed as in items.GroupDuplicates. At most top domains are listed; ages are measured from now.
+func Build(fetched []models.Item, groups map[string][]models.Item, vault string, top int, now time.Time) Stats {
+	s := Stats{GeneratedAt: now.UTC(), Vault: vault, TotalItems: len(fetched)}
+
+	vaults := make(map[string]*Vault)
+	vaultOf := func(item models.Item) *Vault {
+		name := vaultName(item)
+		if vaults[name] == nil {
+			vaults[name] = &Vault{Name: name}
+		}
+		return vaults[name]
+	}
+
+	ages := make([]AgeBucket, len(ageBuckets)+1)
+	for i, bucket := range ageBuckets {
+		ages[i].Label = bucket.label
+	}
+	ages[len(ageBuckets)].Label = "over 5 years"
+
+	for _, item := range fetched {
+		vaultOf(item).Items++
+		noURL, noUsername := items.MissingGroupingData(item)
+		if noURL {
+			s.NoURL++
+		}
+		if noUsername {
+			s.NoUsername++
+		}
+		ages[ageBucket(item.UpdatedAt, now)].Items++
+	}
+	s.Ages = ages
+
+	domains := make(map[string]*Domain)
+	for key, groupItems := range groups {
+		s.DuplicateGroups++
+		s.DuplicateItems += len(groupItems)
+		s.RedundantItems += len(groupItems) - 1
+
+		name, _, _ := strings.Cut(key, "|")
+		if domains[name] == nil {
+			domains[name] = &Domain{Domain: name}
+		}
+		domains[name].Groups++
+		domains[name].Items += len(groupItems)
+		domains[name].Redundant += len(groupItems) - 1
+
+		for _, item := range groupItems {
+			vaultOf(item).DuplicateItems++
+		}
+	}
+
+	s.TopDomains = make([]Domain, 0, len(domains))
+	for _, d := range domains {
+		s.TopDomains = append(s.TopDomains, *d)
+	}
+	sort.Slice(s.TopDomains, func(i, j int) bool {
+		a, b := s.TopDomains[i], s.TopDomains[j]
+		if a.Redundant != b.Redundant {
+			return a.Redundant > b.Redundant
+		}
+		return a.Domain < b.Domain
+	})
+	if top >= 0 && len(s.TopDomains) > top {
+		s.TopDomains = s.TopDomains[:top]
+	}
+
+	s.Vaults = make([]Vault, 0, len(vaults))
+	for _, v := range vaults {
+		s.Vaults = append(s.Vaults, *v)
+	}
+	sort.Slice(s.Vaults, func(i, j int) bool {
+		if s.Vaults[i].Items != s.Vaults[j].Items {
+			return s.Vaults[i].Items > s.Vaults[j].Items
+		}
+		return s.Vaults[i].Name < s.Vaults[j].Name
+	})
+	return s
+}
+
+// ageBucket returns the index of the histogram bucket for an item last updated at updated.
+func ageBucket(updated, now time.Time) int {
+	for i, bucket := range ageBuckets {
+		if updated.After(now.AddDate(-bucket.years, -bucket.months, 0)) {
+			return i
+		}
+	}
+	return len(ageBuckets)
+}
+
+// vaultName names an item's vault, falling back to its ID.
+func vaultName(item models.Item) string {
+	if item.Vault.Name != "" {
+		return item.Vault.Name
+	}
+	if item.Vault.ID != "" {
+		return item.Vault.ID
+	}
+	return "(unknown vault)"
+}
+
+// WriteText writes the statistics as plain-text tables.
+func WriteText(w io.Writer, s Stats) {
+	percent := func(n int) string {
+		if s.TotalItems == 0 {
+			return "0%"
+		}
+		return fmt.Sprintf("%.0f%%", float64(n)*100/float64(s.TotalItems))
+	}
+
+	fmt.Fprintln(w, "=== Vault Statistics ===")
+	fmt.Fprintf(w, "Total items:       %d\n", s.TotalItems)
+	fmt.Fprintf(w, "Duplicate groups:  %d\n", s.DuplicateGroups)
+	fmt.Fprintf(w, "Duplicate items:   %d (%s)\n", s.DuplicateItems, percent(s.DuplicateItems))
+	fmt.Fprintf(w, "Redundant items:   %d (%s would be archived by merging every group)\n", s.RedundantItems, percent(s.RedundantItems))
+	fmt.Fprintf(w, "Without URL:       %d (not checked for duplicates)\n", s.NoURL)
+	fmt.Fprintf(w, "Without username:  %d (not checked for duplicates)\n", s.NoUsername)
+
+	if len(s.TopDomains) > 0 {
+		fmt.Fprintln(w, "\nTop domains by redundant items:")
+		fmt.Fprintf(w, "  %-32s %8s %8s %10s\n", "Domain", "Groups", "Items", "Redundant")
+		for _, d := range s.TopDomains {
+			fmt.Fprintf(w, "  %-32s %8d %8d %10d\n", d.Domain, d.Groups, d.Items, d.Redundant)
+		}
+	}
+
+	if len(s.Vaults) > 0 {
+		fmt.Fprintln(w, "\nItems per vault:")
+		fmt.Fprintf(w, "  %-32s %8s %10s\n", "Vault", "Items", "Duplicates")
+		for _, v := range s.Vaults {
+			fmt.Fprintf(w, "  %-32s %8d %10d\n", v.Name, v.Items, v.DuplicateItems)
+		}
+	}
+
+	fmt.Fprintln(w, "\nLast updated:")
+	largest := 0
+	for _, bucket := range s.Ages {
+		largest = max(largest, bucket.Items)
+	}
+	for _, bucket := range s.Ages {
+		bar := 0
+		if largest > 0 {
+			bar = (bucket.Items*30 + largest - 1) / largest
+		}
+		fmt.Fprintf(w, "  %-14s %6d %s\n", bucket.Label, bucket.Items, strings.Repeat("#", bar))
+	}
+}
@@ -0,0 +1,95 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"1merge/internal/models"
+)
+
+func TestBuild(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	item := func(id, vault, href, username string, updated time.Time) models.Item {
+		it := models.Item{ID: id, Vault: models.Vault{Name: vault}, AdditionalInformation: username, UpdatedAt: updated}
+		if href != "" {
+			it.URLs = []models.URL{{HRef: href, Primary: true}}
+		}
+		return it
+	}
+
+	fetched := []models.Item{
+		item("g1", "Private", "https://google.com", "me", now.AddDate(0, 0, -3)),
+		item("g2", "Private", "https://mail.google.com", "me", now.AddDate(0, -3, 0)),
+		item("g3", "Work", "https://google.com", "me", now.AddDate(-3, 0, 0)),
+		item("a1", "Private", "https://amazon.com", "me", now.AddDate(-1, -6, 0)),
+		item("a2", "Work", "https://amazon.com", "me", now.AddDate(-10, 0, 0)),
+		item("n1", "Private", "", "me", now.AddDate(0, -8, 0)),
+		item("n2", "Work", "https://github.com", "", now.AddDate(0, -8, 0)),
+		item("n3", "Work", "", "", now.AddDate(0, -8, 0)),
+	}
+	groups := map[string][]models.Item{
+		"google.com|me": fetched[0:3],
+		"amazon.com|me": fetched[3:5],
+	}
+
+	s := Build(fetched, groups, "", 1, now)
+
+	if s.TotalItems != 8 || s.DuplicateGroups != 2 || s.DuplicateItems != 5 || s.RedundantItems != 3 {
+		t.Fatalf("unexpected totals: %+v", s)
+	}
+	if s.NoURL != 2 || s.NoUsername != 2 {
+		t.Fatalf("NoURL = %d, NoUsername = %d; expected 2 and 2", s.NoURL, s.NoUsername)
+	}
+	if len(s.TopDomains) != 1 || s.TopDomains[0] != (Domain{Domain: "google.com", Groups: 1, Items: 3, Redundant: 2}) {
+		t.Fatalf("TopDomains = %+v, expected only google.com", s.TopDomains)
+	}
+
+	expectedVaults := []Vault{{Name: "Private", Items: 4, DuplicateItems: 3}, {Name: "Work", Items: 4, DuplicateItems: 2}}
+	if len(s.Vaults) != len(expectedVaults) {
+		t.Fatalf("Vaults = %+v, expected %+v", s.Vaults, expectedVaults)
+	}
+	for i, v := range expectedVaults {
+		if s.Vaults[i] != v {
+			t.Fatalf("Vaults[%d] = %+v, expected %+v", i, s.Vaults[i], v)
+		}
+	}
+
+	expectedAges := []int{1, 1, 3, 1, 1, 1}
+	for i, n := range expectedAges {
+		if s.Ages[i].Items != n {
+			t.Fatalf("Ages[%d] (%s) = %d, expected %d; all: %+v", i, s.Ages[i].Label, s.Ages[i].Items, n, s.Ages)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	s := Stats{
+		TotalItems: 10, DuplicateGroups: 2, DuplicateItems: 5, RedundantItems: 3,
+		TopDomains: []Domain{{Domain: "google.com", Groups: 1, Items: 3, Redundant: 2}},
+		Vaults:     []Vault{{Name: "Private", Items: 10, DuplicateItems: 5}},
+		Ages:       []AgeBucket{{Label: "under 1 month", Items: 4}, {Label: "over 5 years", Items: 0}},
+	}
+
+	var text bytes.Buffer
+	WriteText(&text, s)
+	for _, want := range []string{"Redundant items:   3 (30%", "google.com", "Private", "under 1 month       4 ###"} {
+		if !strings.Contains(text.String(), want) {
+			t.Fatalf("expected %q in text output:\n%s", want, text.String())
+		}
+	}
+
+	doc, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("failed to marshal statistics: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(doc, &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded["redundant_items"] != float64(3) || decoded["top_domains"] == nil {
+		t.Fatalf("unexpected JSON document: %s", doc)
+	}
+}
@@ -0,0 +1,357 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"1merge/internal/items"
+	"1merge/internal/models"
+)
+
+// Action is what the user decided to do with a group.
+type Action string
+
+const (
+	// ActionNone means the group has not been decided yet; it is left alone.
+	ActionNone Action = ""
+	// ActionMerge merges the group into its winner.
+	ActionMerge Action = "merge"
+	// ActionSkip leaves the group alone in this run.
+	ActionSkip Action = "skip"
+	// ActionIgnore leaves the group alone in this and every later run.
+	ActionIgnore Action = "ignore"
+)
+
+// Group is a duplicate group shown in the review.
+type Group struct {
+	Key   string
+	Items []models.Item
+}
+
+// Decision is the queued decision for a group.
+type Decision struct {
+	Action Action
+	// WinnerID is the item that survives a merge.
+	WinnerID string
+}
+
+// screen is the part of the UI currently shown.
+type screen int
+
+const (
+	screenList screen = iota
+	screenFilter
+	screenConfirm
+	screenHelp
+)
+
+// Key is a key press: a printable rune or one of the special keys below.
+type Key rune
+
+// Special keys, outside the range of valid runes.
+const (
+	KeyUp Key = -(iota + 1)
+	KeyDown
+	KeyEnter
+	KeyEscape
+	KeyBackspace
+	KeyPageUp
+	KeyPageDown
+	KeyInterrupt
+)
+
+// Model is the state of the review UI. It is driven by HandleKey and drawn by View,
+// so it can be tested without a terminal.
+type Model struct {
+	groups    []Group
+	decisions map[string]Decision
+	// defaultWinners holds each group's automatic winner; groups that cannot be merged are in blocked.
+	defaultWinners map[string]string
+	blocked        map[string]string
+
+	visible []int
+	cursor  int
+	filter  string
+	screen  screen
+	message string
+
+	done      bool
+	confirmed bool
+}
+
+// NewModel returns a review of the given groups with nothing decided yet.
+func NewModel(groups []Group) *Model {
+	m := &Model{
+		groups:         groups,
+		decisions:      make(map[string]Decision),
+		defaultWinners: make(map[string]string),
+		blocked:        make(map[string]string),
+	}
+	for _, group := range groups {
+		winner, err := items.SelectWinnerForMerge(group.Items)
+		if err != nil {
+			m.blocked[group.Key] = "several items hold passkeys"
+			continue
+		}
+		m.defaultWinners[group.Key] = winner.ID
+	}
+	m.applyFilter()
+	return m
+}
+
+// Done reports whether the user has left the review.
+func (m *Model) Done() bool {
+	return m.done
+}
+
+// Confirmed reports whether the user confirmed the queued decisions on the final screen.
+func (m *Model) Confirmed() bool {
+	return m.confirmed
+}
+
+// Decisions returns the decided groups by key.
+func (m *Model) Decisions() map[string]Decision {
+	decisions := make(map[string]Decision, len(m.decisions))
+	for key, decision := range m.decisions {
+		decisions[key] = decision
+	}
+	return decisions
+}
+
+// HandleKey updates the model for a key press.
+func (m *Model) HandleKey(key Key) {
+	m.message = ""
+	if key == KeyInterrupt {
+		m.done = true
+		return
+	}
+
+	switch m.screen {
+	case screenFilter:
+		m.handleFilterKey(key)
+	case screenConfirm:
+		m.handleConfirmKey(key)
+	case screenHelp:
+		m.screen = screenList
+	default:
+		m.handleListKey(key)
+	}
+}
+
+func (m *Model) handleListKey(key Key) {
+	switch key {
+	case KeyUp, 'k':
+		m.move(-1)
+	case KeyDown, 'j':
+		m.move(1)
+	case KeyPageUp:
+		m.move(-10)
+	case KeyPageDown:
+		m.move(10)
+	case 'm', 'y':
+		m.decide(ActionMerge)
+	case 's', 'n':
+		m.decide(ActionSkip)
+	case 'i':
+		m.decide(ActionIgnore)
+	case 'u':
+		if group, ok := m.current(); ok {
+			delete(m.decisions, group.Key)
+		}
+	case 'w':
+		m.nextWinner()
+	case '/':
+		m.screen = screenFilter
+	case '?':
+		m.screen = screenHelp
+	case 'a', KeyEnter:
+		m.screen = screenConfirm
+	case 'q':
+		m.done = true
+	}
+}
+
+func (m *Model) handleFilterKey(key Key) {
+	switch key {
+	case KeyEnter:
+		m.screen = screenList
+	case KeyEscape:
+		m.filter = ""
+		m.screen = screenList
+		m.applyFilter()
+	case KeyBackspace:
+		if m.filter != "" {
+			runes := []rune(m.filter)
+			m.filter = string(runes[:len(runes)-1])
+			m.applyFilter()
+		}
+	default:
+		if key > 0 {
+			m.filter += string(rune(key))
+			m.applyFilter()
+		}
+	}
+}
+
+func (m *Model) handleConfirmKey(key Key) {
+	switch key {
+	case 'y':
+		m.confirmed = true
+		m.done = true
+	case 'n', KeyEscape, 'q':
+		m.screen = screenList
+	}
+}
+
+// move moves the cursor by delta rows within the visible groups.
+func (m *Model) move(delta int) {
+	if len(m.visible) == 0 {
+		return
+	}
+	m.cursor += delta
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	if m.cursor >= len(m.visible) {
+		m.cursor = len(m.visible) - 1
+	}
+}
+
+// current returns the group under the cursor.
+func (m *Model) current() (Group, bool) {
+	if len(m.visible) == 0 {
+		return Group{}, false
+	}
+	return m.groups[m.visible[m.cursor]], true
+}
+
+// decide queues an action for the group under the cursor and moves to the next group.
+func (m *Model) decide(action Action) {
+	group, ok := m.current()
+	if !ok {
+		return
+	}
+	if action == ActionMerge {
+		if reason, blocked := m.blocked[group.Key]; blocked {
+			m.message = "Cannot merge: " + reason
+			return
+		}
+	}
+	decision := m.decisions[group.Key]
+	decision.Action = action
+	if decision.WinnerID == "" {
+		decision.WinnerID = m.defaultWinners[group.Key]
+	}
+	m.decisions[group.Key] = decision
+	m.move(1)
+}
+
+// nextWinner makes the next eligible item of the current group its winner.
+// When one item holds a passkey, it is the only eligible winner.
+func (m *Model) nextWinner() {
+	group, ok := m.current()
+	if !ok {
+		return
+	}
+	if reason, blocked := m.blocked[group.Key]; blocked {
+		m.message = "Cannot merge: " + reason
+		return
+	}
+
+	var eligible []models.Item
+	for _, item := range group.Items {
+		if items.HasPasskey(item) {
+			eligible = []models.Item{item}
+			break
+		}
+		eligible = append(eligible, item)
+	}
+	if len(eligible) == 1 {
+		m.message = fmt.Sprintf("%q holds a passkey and must stay the winner", eligible[0].Title)
+		return
+	}
+
+	current := m.winnerID(group)
+	next := eligible[0].ID
+	for i, item := range eligible {
+		if item.ID == current {
+			next = eligible[(i+1)%len(eligible)].ID
+		}
+	}
+
+	decision := m.decisions[group.Key]
+	decision.WinnerID = next
+	m.decisions[group.Key] = decision
+}
+
+// winnerID returns the chosen or default winner of a group.
+func (m *Model) winnerID(group Group) string {
+	if decision, ok := m.decisions[group.Key]; ok && decision.WinnerID != "" {
+		return decision.WinnerID
+	}
+	return m.defaultWinners[group.Key]
+}
+
+// applyFilter recomputes the visible groups. The filter matches the group's domain or the
+// vault of any of its items, ignoring case.
+func (m *Model) applyFilter() {
+	m.visible = m.visible[:0]
+	filter := strings.ToLower(strings.TrimSpace(m.filter))
+	for i, group := range m.groups {
+		if filter == "" || groupMatches(group, filter) {
+			m.visible = append(m.visible, i)
+		}
+	}
+	m.cursor = 0
+}
+
+// groupMatches reports whether a group's domain or one of its vaults contains filter.
+func groupMatches(group Group, filter string) bool {
+	domain, _, _ := strings.Cut(group.Key, "|")
+	if strings.Contains(strings.ToLower(domain), filter) {
+		return true
+	}
+	for _, item := range group.Items {
+		if strings.Contains(strings.ToLower(item.Vault.Name), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldDiff describes how a field label differs across a group's items, without values.
+type fieldDiff struct {
+	label    string
+	present  int
+	distinct int
+}
+
+// diffFields compares the fields of a group by label. Values are only counted, never shown.
+func diffFields(groupItems []models.Item) []fieldDiff {
+	values := make(map[string]map[string]bool)
+	present := make(map[string]int)
+	var labels []string
+	for _, item := range groupItems {
+		seen := make(map[string]bool)
+		for _, field := range item.Fields {
+			if field.Label == "" || seen[field.Label] {
+				continue
+			}
+			seen[field.Label] = true
+			if values[field.Label] == nil {
+				values[field.Label] = make(map[string]bool)
+				labels = append(labels, field.Label)
+			}
+			values[field.Label][field.Value] = true
+			present[field.Label]++
+		}
+	}
+	sort.Strings(labels)
+
+	diffs := make([]fieldDiff, len(labels))
+	for i, label := range labels {
+		diffs[i] = fieldDiff{label: label, present: present[label], distinct: len(values[label])}
+	}
+	return diffs
+}
@@ -0,0 +1,192 @@
+package tui
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"time"
+
+	"1merge/internal/models"
+)
+
+func testGroups() []Group {
+	passkey := models.Field{Type: "PASSKEY", Label: "passkey", Value: "pk"}
+	return []Group{
+		{Key: "amazon.com|me", Items: []models.Item{
+			{ID: "a1", Title: "Amazon", Vault: models.Vault{Name: "Private"}, UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				Fields: []models.Field{{Label: "password", Value: "s3cret-one"}, {Label: "pin", Value: "pin-4711"}}},
+			{ID: "a2", Title: "Amazon (2)", Vault: models.Vault{Name: "Private"}, UpdatedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				Fields: []models.Field{{Label: "password", Value: "s3cret-two"}}},
+		}},
+		{Key: "github.com|me", Items: []models.Item{
+			{ID: "g1", Title: "GitHub", Vault: models.Vault{Name: "Work"}, UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				Fields: []models.Field{passkey}},
+			{ID: "g2", Title: "GitHub old", Vault: models.Vault{Name: "Work"}, UpdatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		}},
+		{Key: "google.com|me", Items: []models.Item{
+			{ID: "o1", Title: "Google", Vault: models.Vault{Name: "Private"}, Fields: []models.Field{passkey}},
+			{ID: "o2", Title: "Gmail", Vault: models.Vault{Name: "Private"}, Fields: []models.Field{passkey}},
+		}},
+	}
+}
+
+func press(m *Model, keys ...Key) {
+	for _, key := range keys {
+		m.HandleKey(key)
+	}
+}
+
+func TestModel_QueueAndConfirm(t *testing.T) {
+	m := NewModel(testGroups())
+
+	// Merge amazon, skip github, then go back and ignore github instead
+	press(m, 'm', 's', 'k', 'i')
+	// The google group has two passkeys and cannot be merged
+	press(m, 'j', 'm')
+	if !strings.HasPrefix(m.message, "Cannot merge") {
+		t.Fatalf("expected blocked message, got %q", m.message)
+	}
+
+	press(m, 'a')
+	if m.screen != screenConfirm {
+		t.Fatalf("expected confirm screen, got %v", m.screen)
+	}
+	press(m, KeyEscape)
+	if m.screen != screenList || m.Done() {
+		t.Fatal("Escape should return to the list")
+	}
+	press(m, KeyEnter, 'y')
+	if !m.Done() || !m.Confirmed() {
+		t.Fatal("expected the review to be confirmed")
+	}
+
+	expected := map[string]Decision{
+		"amazon.com|me": {Action: ActionMerge, WinnerID: "a2"},
+		"github.com|me": {Action: ActionIgnore, WinnerID: "g1"},
+	}
+	decisions := m.Decisions()
+	if len(decisions) != len(expected) {
+		t.Fatalf("expected %d decisions, got %v", len(expected), decisions)
+	}
+	for key, want := range expected {
+		if decisions[key] != want {
+			t.Fatalf("decision for %s = %+v, expected %+v", key, decisions[key], want)
+		}
+	}
+}
+
+func TestModel_UndoAndQuit(t *testing.T) {
+	m := NewModel(testGroups())
+	press(m, 'm', 'k', 'u', 'q')
+	if !m.Done() || m.Confirmed() {
+		t.Fatal("q should leave without confirming")
+	}
+	if len(m.Decisions()) != 0 {
+		t.Fatalf("expected no decisions after undo, got %v", m.Decisions())
+	}
+}
+
+func TestModel_ChooseWinner(t *testing.T) {
+	m := NewModel(testGroups())
+
+	// The newest item wins by default; w cycles through the group
+	press(m, 'w')
+	if got := m.winnerID(m.groups[0]); got != "a1" {
+		t.Fatalf("winner = %q, expected a1", got)
+	}
+	press(m, 'w')
+	if got := m.winnerID(m.groups[0]); got != "a2" {
+		t.Fatalf("winner = %q, expected a2", got)
+	}
+
+	// A chosen winner is kept when the group is merged
+	press(m, 'w', 'm')
+	if got := m.Decisions()["amazon.com|me"]; got.WinnerID != "a1" || got.Action != ActionMerge {
+		t.Fatalf("decision = %+v, expected merge into a1", got)
+	}
+
+	// The passkey holder cannot be replaced
+	press(m, 'w')
+	if got := m.winnerID(m.groups[1]); got != "g1" {
+		t.Fatalf("winner = %q, expected passkey holder g1", got)
+	}
+	if !strings.Contains(m.message, "passkey") {
+		t.Fatalf("expected passkey message, got %q", m.message)
+	}
+}
+
+func TestModel_Filter(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   string
+		expected []string
+	}{
+		{"domain", "git", []string{"github.com|me"}},
+		{"vault ignores case", "private", []string{"amazon.com|me", "google.com|me"}},
+		{"no match", "nothing", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewModel(testGroups())
+			press(m, '/')
+			for _, r := range tt.filter {
+				press(m, Key(r))
+			}
+			press(m, KeyEnter)
+
+			var keys []string
+			for _, i := range m.visible {
+				keys = append(keys, m.groups[i].Key)
+			}
+			if strings.Join(keys, ",") != strings.Join(tt.expected, ",") {
+				t.Fatalf("visible = %v, expected %v", keys, tt.expected)
+			}
+
+			// Escape clears the filter again
+			press(m, '/', KeyEscape)
+			if len(m.visible) != len(m.groups) {
+				t.Fatalf("expected all groups after clearing the filter, got %d", len(m.visible))
+			}
+		})
+	}
+}
+
+func TestView_NeverShowsFieldValues(t *testing.T) {
+	m := NewModel(testGroups())
+	press(m, 'm', 'k')
+
+	screens := []string{m.View(120, 30)}
+	press(m, 'a')
+	screens = append(screens, m.View(120, 30))
+
+	for _, view := range screens {
+		for _, secret := range []string{"s3cret-one", "s3cret-two", "pin-4711"} {
+			if strings.Contains(view, secret) {
+				t.Fatalf("view shows field value %q:\n%s", secret, view)
+			}
+		}
+	}
+	if !strings.Contains(screens[0], "password: 2 different values") || !strings.Contains(screens[0], "pin: only in 1 of 2") {
+		t.Fatalf("expected field diff in detail pane:\n%s", screens[0])
+	}
+	if !strings.Contains(screens[1], `merge  amazon.com / me -> "Amazon (2)"`) {
+		t.Fatalf("expected queued merge on confirm screen:\n%s", screens[1])
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	input := "j\x1b[A\x1b[B\x1b[5~\x1b[6~\r\x7f\x03"
+	expected := []Key{'j', KeyUp, KeyDown, KeyPageUp, KeyPageDown, KeyEnter, KeyBackspace, KeyInterrupt}
+
+	reader := bufio.NewReader(strings.NewReader(input))
+	for _, want := range expected {
+		got, err := readKey(reader)
+		if err != nil {
+			t.Fatalf("readKey returned error: %v", err)
+		}
+		if got != want {
+			t.Fatalf("readKey = %v, expected %v", got, want)
+		}
+	}
+}
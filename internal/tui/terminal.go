@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+const (
+	enterAltScreen = "\x1b[?1049h\x1b[?25l"
+	exitAltScreen  = "\x1b[?25h\x1b[?1049l"
+	clearScreen    = "\x1b[H\x1b[2J"
+)
+
+// Run shows the review for groups on the terminal in and out until the user confirms or quits.
+// The terminal is restored before Run returns.
+func Run(in *os.File, out io.Writer, groups []Group) (*Model, error) {
+	fd := int(in.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, errors.New("the TUI needs an interactive terminal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to switch the terminal to raw mode: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	fmt.Fprint(out, enterAltScreen)
+	defer fmt.Fprint(out, exitAltScreen)
+
+	model := NewModel(groups)
+	reader := bufio.NewReader(in)
+	for !model.Done() {
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			width, height = 100, 30
+		}
+		fmt.Fprint(out, clearScreen+model.View(width, height))
+
+		key, err := readKey(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key: %w", err)
+		}
+		model.HandleKey(key)
+	}
+	return model, nil
+}
+
+// readKey reads one key press, decoding the escape sequences of the keys the UI uses.
+func readKey(reader *bufio.Reader) (Key, error) {
+	r, _, err := reader.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	switch r {
+	case '\r', '\n':
+		return KeyEnter, nil
+	case 0x7f, 0x08:
+		return KeyBackspace, nil
+	case 0x03, 0x04:
+		return KeyInterrupt, nil
+	case 0x1b:
+		return readEscape(reader)
+	}
+	return Key(r), nil
+}
+
+// readEscape decodes the rest of an escape sequence. A lone Escape has nothing buffered after it.
+func readEscape(reader *bufio.Reader) (Key, error) {
+	if reader.Buffered() == 0 {
+		return KeyEscape, nil
+	}
+	next, _, err := reader.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if next != '[' && next != 'O' {
+		return KeyEscape, nil
+	}
+
+	seq := ""
+	for {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		seq += string(r)
+		if r >= 0x40 && r <= 0x7e {
+			break
+		}
+	}
+	switch seq {
+	case "A":
+		return KeyUp, nil
+	case "B":
+		return KeyDown, nil
+	case "5~":
+		return KeyPageUp, nil
+	case "6~":
+		return KeyPageDown, nil
+	}
+	// Keys the UI does not use are ignored
+	return 0, nil
+}
@@ -0,0 +1,284 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"1merge/internal/items"
+	"1merge/internal/models"
+)
+
+const (
+	reverseVideo = "\x1b[7m"
+	resetStyle   = "\x1b[0m"
+	// listWidth is the share of the screen width, in percent, used by the group list.
+	listWidth = 45
+)
+
+// View renders the current screen for a terminal of the given size. Lines are separated by
+// "\r\n" so the output is correct in raw mode.
+func (m *Model) View(width, height int) string {
+	if width < 40 {
+		width = 40
+	}
+	if height < 10 {
+		height = 10
+	}
+
+	var lines []string
+	switch m.screen {
+	case screenHelp:
+		lines = helpLines()
+	case screenConfirm:
+		lines = m.confirmLines(height - 2)
+	default:
+		lines = m.listLines(width, height-2)
+	}
+	for len(lines) < height-2 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, "", m.statusLine())
+
+	for i, line := range lines {
+		lines[i] = truncate(line, width)
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+// listLines renders the group list next to the details of the selected group.
+func (m *Model) listLines(width, height int) []string {
+	left := width * listWidth / 100
+	right := width - left - 3
+
+	var list []string
+	title := fmt.Sprintf("Groups (%d/%d)", len(m.visible), len(m.groups))
+	if m.filter != "" || m.screen == screenFilter {
+		title += fmt.Sprintf(" filter: %s", m.filter)
+	}
+	list = append(list, title, strings.Repeat("-", left))
+
+	// Scroll so the cursor stays on screen
+	rows := height - len(list)
+	first := 0
+	if m.cursor >= rows {
+		first = m.cursor - rows + 1
+	}
+	for i := first; i < len(m.visible) && i < first+rows; i++ {
+		group := m.groups[m.visible[i]]
+		row := pad(fmt.Sprintf("%s %s (%d)", m.marker(group.Key), groupLabel(group.Key), len(group.Items)), left)
+		if i == m.cursor {
+			row = reverseVideo + row + resetStyle
+		}
+		list = append(list, row)
+	}
+	if len(m.visible) == 0 {
+		list = append(list, "No groups match the filter.")
+	}
+
+	var detail []string
+	if group, ok := m.current(); ok {
+		detail = m.detailLines(group)
+	}
+
+	lines := make([]string, 0, height)
+	for i := 0; i < height; i++ {
+		var l, r string
+		if i < len(list) {
+			l = list[i]
+		}
+		if i < len(detail) {
+			r = truncate(detail[i], right)
+		}
+		if !strings.HasPrefix(l, reverseVideo) {
+			l = pad(truncate(l, left), left)
+		}
+		lines = append(lines, l+" | "+r)
+	}
+	return lines
+}
+
+// detailLines renders the items of a group and how their fields differ. Field values are never shown.
+func (m *Model) detailLines(group Group) []string {
+	domain, username, _ := strings.Cut(group.Key, "|")
+	lines := []string{
+		fmt.Sprintf("%s | %s", domain, username),
+		"",
+	}
+
+	winner := m.winnerID(group)
+	for i, item := range group.Items {
+		mark := " "
+		if item.ID == winner {
+			mark = "*"
+		}
+		lines = append(lines, fmt.Sprintf("%s %d. %q", mark, i+1, item.Title))
+		lines = append(lines, fmt.Sprintf("     Vault: %s  Updated: %s", item.Vault.Name, item.UpdatedAt.Format("2006-01-02")))
+		if len(item.URLs) > 0 {
+			lines = append(lines, "     URL: "+item.URLs[0].HRef)
+		}
+		if flags := itemFlags(item); flags != "" {
+			lines = append(lines, "     "+flags)
+		}
+	}
+	if reason, blocked := m.blocked[group.Key]; blocked {
+		lines = append(lines, "", "Blocked: "+reason)
+	}
+
+	diffs := diffFields(group.Items)
+	if len(diffs) > 0 {
+		lines = append(lines, "", "Fields:")
+	}
+	for _, diff := range diffs {
+		state := "same"
+		switch {
+		case diff.distinct > 1:
+			state = fmt.Sprintf("%d different values", diff.distinct)
+		case diff.present < len(group.Items):
+			state = fmt.Sprintf("only in %d of %d", diff.present, len(group.Items))
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %s", diff.label, state))
+	}
+	return lines
+}
+
+// confirmLines renders the queued decisions before they are applied.
+func (m *Model) confirmLines(height int) []string {
+	counts := make(map[Action]int)
+	var lines []string
+	for _, group := range m.groups {
+		decision, ok := m.decisions[group.Key]
+		if !ok || decision.Action == ActionNone {
+			continue
+		}
+		counts[decision.Action]++
+		line := fmt.Sprintf("  %-6s %s", decision.Action, groupLabel(group.Key))
+		if decision.Action == ActionMerge {
+			line += fmt.Sprintf(" -> %q", itemTitle(group.Items, decision.WinnerID))
+		}
+		lines = append(lines, line)
+	}
+
+	undecided := len(m.groups) - counts[ActionMerge] - counts[ActionSkip] - counts[ActionIgnore]
+	header := []string{
+		"Apply these decisions?",
+		fmt.Sprintf("Merge: %d  Skip: %d  Ignore: %d  Undecided (left alone): %d",
+			counts[ActionMerge], counts[ActionSkip], counts[ActionIgnore], undecided),
+		"",
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "  No decisions queued.")
+	}
+	if room := height - len(header) - 2; len(lines) > room && room > 0 {
+		hidden := len(lines) - room + 1
+		lines = append(lines[:room-1], fmt.Sprintf("  ... and %d more", hidden))
+	}
+	lines = append(header, lines...)
+	return append(lines, "", "y: apply   n/Esc: back to the list")
+}
+
+// helpLines lists the keyboard shortcuts.
+func helpLines() []string {
+	return []string{
+		"Keys",
+		"",
+		"  j/k, arrows    move between groups (PgUp/PgDn jump by 10)",
+		"  m or y         merge the selected group",
+		"  s or n         skip the selected group in this run",
+		"  i              ignore the selected group in this and later runs",
+		"  u              undo the decision for the selected group",
+		"  w              choose the next item as the winner",
+		"  /              filter groups by domain or vault (Esc clears)",
+		"  a or Enter     review and apply all decisions",
+		"  q              quit without applying anything",
+		"",
+		"Press any key to return.",
+	}
+}
+
+// statusLine shows the key hints, or a message from the last key press.
+func (m *Model) statusLine() string {
+	if m.message != "" {
+		return m.message
+	}
+	switch m.screen {
+	case screenFilter:
+		return "Type to filter, Enter to keep, Esc to clear"
+	case screenConfirm, screenHelp:
+		return ""
+	}
+	return "m merge  s skip  i ignore  u undo  w winner  / filter  a apply  ? help  q quit"
+}
+
+// marker shows the queued action of a group in the list.
+func (m *Model) marker(key string) string {
+	switch m.decisions[key].Action {
+	case ActionMerge:
+		return "[M]"
+	case ActionSkip:
+		return "[S]"
+	case ActionIgnore:
+		return "[I]"
+	}
+	if _, blocked := m.blocked[key]; blocked {
+		return "[!]"
+	}
+	return "[ ]"
+}
+
+// itemFlags summarises what an item carries besides its fields.
+func itemFlags(item models.Item) string {
+	var flags []string
+	if len(item.Files) > 0 {
+		flags = append(flags, fmt.Sprintf("Attachments: %d", len(item.Files)))
+	}
+	if items.HasOTP(item) {
+		flags = append(flags, "OTP")
+	}
+	if items.HasPasskey(item) {
+		flags = append(flags, "Passkey")
+	}
+	return strings.Join(flags, "  ")
+}
+
+// groupLabel formats a group key (domain|username) for display.
+func groupLabel(key string) string {
+	domain, username, _ := strings.Cut(key, "|")
+	if username == "" {
+		return domain
+	}
+	return domain + " / " + username
+}
+
+// itemTitle returns the title of the item with the given ID.
+func itemTitle(groupItems []models.Item, id string) string {
+	for _, item := range groupItems {
+		if item.ID == id {
+			return item.Title
+		}
+	}
+	return id
+}
+
+// truncate shortens s to at most width runes. Styled rows are left alone.
+func truncate(s string, width int) string {
+	if strings.Contains(s, reverseVideo) {
+		return s
+	}
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-1]) + "~"
+}
+
+// pad fills s with spaces up to width runes, truncating it if it is longer.
+func pad(s string, width int) string {
+	s = truncate(s, width)
+	if n := len([]rune(s)); n < width {
+		s += strings.Repeat(" ", width-n)
+	}
+	return s
+}
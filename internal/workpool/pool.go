@@ -0,0 +1,96 @@
+package workpool
+
+import "sync"
+
+// Pool runs submitted jobs on at most a fixed number of goroutines at a time and
+// hands their results back in submission order, whatever order they finish in.
+// Submit, Ready, Next and Pending must be called from a single goroutine.
+type Pool[T any] struct {
+	slots   chan struct{}
+	results []chan T
+	next    int
+}
+
+// New returns a pool that runs at most workers jobs at once (minimum 1).
+func New[T any](workers int) *Pool[T] {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Pool[T]{slots: make(chan struct{}, workers)}
+}
+
+// Submit schedules job to run as soon as a worker slot is free. It never blocks.
+func (p *Pool[T]) Submit(job func() T) {
+	result := make(chan T, 1)
+	p.results = append(p.results, result)
+
+	go func() {
+		p.slots <- struct{}{}
+		defer func() { <-p.slots }()
+		result <- job()
+	}()
+}
+
+// Pending returns the number of submitted jobs whose results have not been handed back yet.
+func (p *Pool[T]) Pending() int {
+	return len(p.results) - p.next
+}
+
+// Next blocks until the next result in submission order is available and returns it.
+// It returns false if every submitted result has already been handed back.
+func (p *Pool[T]) Next() (T, bool) {
+	if p.Pending() == 0 {
+		var zero T
+		return zero, false
+	}
+	value := <-p.results[p.next]
+	p.results[p.next] = nil
+	p.next++
+	return value, true
+}
+
+// Ready returns, without blocking, the results that are available in submission order:
+// it stops at the first job that is still running, even if later jobs have finished.
+func (p *Pool[T]) Ready() []T {
+	var ready []T
+	for p.Pending() > 0 {
+		select {
+		case value := <-p.results[p.next]:
+			p.results[p.next] = nil
+			p.next++
+			ready = append(ready, value)
+		default:
+			return ready
+		}
+	}
+	return ready
+}
+
+// Map applies fn to every input using at most workers goroutines and returns the
+// outputs in input order.
+func Map[In, Out any](inputs []In, workers int, fn func(In) Out) []Out {
+	if workers < 1 {
+		workers = 1
+	}
+	outputs := make([]Out, len(inputs))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(inputs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				outputs[i] = fn(inputs[i])
+			}
+		}()
+	}
+
+	for i := range inputs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return outputs
+}
@@ -0,0 +1,138 @@
+package workpool
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool_ResultsInSubmissionOrder(t *testing.T) {
+	const jobs = 8
+	pool := New[int](jobs)
+
+	// Each job waits for its gate; the gates are opened last job first, one job at a time
+	gates := make([]chan struct{}, jobs)
+	finished := make(chan int)
+	for i := 0; i < jobs; i++ {
+		gates[i] = make(chan struct{})
+		value := i
+		pool.Submit(func() int {
+			<-gates[value]
+			finished <- value
+			return value
+		})
+	}
+
+	if pool.Pending() != jobs {
+		t.Fatalf("expected %d pending results, got %d", jobs, pool.Pending())
+	}
+
+	for i := jobs - 1; i >= 0; i-- {
+		close(gates[i])
+		if got := <-finished; got != i {
+			t.Fatalf("expected job %d to finish, got %d", i, got)
+		}
+	}
+
+	for want := 0; want < jobs; want++ {
+		got, ok := pool.Next()
+		if !ok {
+			t.Fatalf("Next returned no result for job %d", want)
+		}
+		if got != want {
+			t.Fatalf("expected result %d, got %d", want, got)
+		}
+	}
+
+	if _, ok := pool.Next(); ok {
+		t.Fatal("expected Next to report no more results")
+	}
+}
+
+func TestPool_BoundsConcurrency(t *testing.T) {
+	const workers = 3
+	var running, maxRunning int32
+	started := make(chan struct{}, 12)
+	release := make(chan struct{})
+
+	pool := New[struct{}](workers)
+	for i := 0; i < 12; i++ {
+		pool.Submit(func() struct{} {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			started <- struct{}{}
+			<-release
+			atomic.AddInt32(&running, -1)
+			return struct{}{}
+		})
+	}
+
+	// Hold the first jobs until every worker is busy, so the pool is full before any job ends
+	for i := 0; i < workers; i++ {
+		<-started
+	}
+	close(release)
+	for pool.Pending() > 0 {
+		pool.Next()
+	}
+
+	if maxRunning != workers {
+		t.Fatalf("expected exactly %d concurrent jobs, saw %d", workers, maxRunning)
+	}
+}
+
+func TestPool_ReadyStopsAtRunningJob(t *testing.T) {
+	pool := New[int](2)
+	release := make(chan struct{})
+	secondDone := make(chan struct{})
+
+	pool.Submit(func() int {
+		<-release
+		return 0
+	})
+	pool.Submit(func() int {
+		close(secondDone)
+		return 1
+	})
+
+	// The second job has finished, but the first is blocked until release is closed
+	<-secondDone
+	if ready := pool.Ready(); len(ready) != 0 {
+		t.Fatalf("expected no ready results while the first job runs, got %v", ready)
+	}
+
+	close(release)
+	var ready []int
+	for len(ready) < 2 {
+		ready = append(ready, pool.Ready()...)
+		runtime.Gosched()
+	}
+	if ready[0] != 0 || ready[1] != 1 {
+		t.Fatalf("expected [0 1], got %v", ready)
+	}
+}
+
+func TestMap(t *testing.T) {
+	inputs := []int{5, 4, 3, 2, 1, 0}
+	outputs := Map(inputs, 3, func(n int) int {
+		time.Sleep(time.Duration(n) * time.Millisecond)
+		return n * n
+	})
+
+	expected := []int{25, 16, 9, 4, 1, 0}
+	for i := range expected {
+		if outputs[i] != expected[i] {
+			t.Fatalf("Map output %v, expected %v", outputs, expected)
+		}
+	}
+
+	if len(Map([]int{}, 4, func(n int) int { return n })) != 0 {
+		t.Fatal("expected empty output for empty input")
+	}
+}